@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"yogo/internal/domain"
+	"yogo/internal/instance"
+	"yogo/internal/ports"
+	"yogo/internal/services/config"
+	"yogo/internal/services/control"
+	"yogo/internal/services/storage"
+	"yogo/internal/services/youtube"
+)
+
+func parseCommand(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func loadConfig() (domain.Config, bool) {
+	cfg, err := config.NewViperConfigService().Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		return cfg, false
+	}
+	return cfg, true
+}
+
+func printJSON(w io.Writer, v any) int {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing JSON: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func formatSong(song domain.Song) string {
+	if len(song.Artists) == 0 {
+		return song.Title
+	}
+	return fmt.Sprintf("%s - %s", song.Title, strings.Join(song.Artists, ", "))
+}
+
+func songURL(song domain.Song) string {
+	return fmt.Sprintf("https://www.youtube.com/watch?v=%s", song.ID)
+}
+
+func historyPath() string {
+	configDir, _ := os.UserConfigDir()
+	return filepath.Join(configDir, "yogo", "history.db")
+}
+
+func interruptible() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+func runSearch(args []string) int {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	limit := fs.Int("limit", 0, "Number of results, defaults to searchLimit from the config")
+	asJSON := fs.Bool("json", false, "Print the results as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: yogo search <query> [--limit N] [--json]")
+		fs.PrintDefaults()
+	}
+	positional, err := parseCommand(fs, args)
+	if err != nil {
+		return 2
+	}
+	query := strings.Join(positional, " ")
+	if query == "" {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, ok := loadConfig()
+	if !ok {
+		return 1
+	}
+	if *limit <= 0 {
+		*limit = cfg.SearchLimit
+	}
+
+	ctx, stop := interruptible()
+	defer stop()
+	ytService := youtube.NewYoutubeService(cfg)
+	defer ytService.Close()
+
+	songs, err := ytService.Search(ctx, query, *limit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error searching: %v\n", err)
+		return 1
+	}
+
+	return printSongs(os.Stdout, songs, *asJSON)
+}
+
+func printSongs(w io.Writer, songs []domain.Song, asJSON bool) int {
+	if asJSON {
+		if songs == nil {
+			songs = []domain.Song{}
+		}
+		return printJSON(w, songs)
+	}
+	for _, song := range songs {
+		fmt.Fprintf(w, "%s\t%s\n", song.ID, formatSong(song))
+	}
+	return 0
+}
+
+func runHistory(args []string) int {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	limit := fs.Int("limit", 0, "Number of entries, defaults to historyLimit from the config")
+	asJSON := fs.Bool("json", false, "Print the history as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: yogo history [--limit N] [--json]")
+		fs.PrintDefaults()
+	}
+	if positional, err := parseCommand(fs, args); err != nil {
+		return 2
+	} else if len(positional) > 0 {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, ok := loadConfig()
+	if !ok {
+		return 1
+	}
+	if *limit <= 0 {
+		*limit = cfg.HistoryLimit
+	}
+
+	runtimeDir, err := instance.RuntimeDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error preparing the runtime directory: %v\n", err)
+		return 1
+	}
+	entries, err := loadHistory(instance.ControlSocketPath(runtimeDir), historyPath(), *limit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading history: %v\n", err)
+		return 1
+	}
+	return printHistory(os.Stdout, entries, *asJSON)
+}
+
+func printHistory(w io.Writer, entries []domain.HistoryEntry, asJSON bool) int {
+	if asJSON {
+		if entries == nil {
+			entries = []domain.HistoryEntry{}
+		}
+		return printJSON(w, entries)
+	}
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", entry.PlayedAt.Format("2006-01-02 15:04"), entry.Song.ID, formatSong(entry.Song))
+	}
+	return 0
+}
+
+func loadHistory(socketPath, dbPath string, limit int) ([]domain.HistoryEntry, error) {
+	// A running yogo holds the database lock, so ask it instead.
+	resp, err := control.Send(socketPath, ports.ControlRequest{Command: "history", Args: []string{strconv.Itoa(limit)}})
+	if err == nil {
+		return resp.History, nil
+	}
+	if resp.Error != "" {
+		return nil, err
+	}
+
+	storageService, err := storage.NewBboltStore(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	defer storageService.Close()
+	return storageService.GetHistory(limit)
+}
+
+func runInfo(args []string) int {
+	fs := flag.NewFlagSet("info", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print the song as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: yogo info <url> [--json]")
+		fs.PrintDefaults()
+	}
+	positional, err := parseCommand(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, ok := loadConfig()
+	if !ok {
+		return 1
+	}
+
+	ctx, stop := interruptible()
+	defer stop()
+	ytService := youtube.NewYoutubeService(cfg)
+	defer ytService.Close()
+
+	song, err := ytService.GetSongInfo(ctx, positional[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting song info: %v\n", err)
+		return 1
+	}
+
+	return printSongInfo(os.Stdout, song, *asJSON)
+}
+
+func printSongInfo(w io.Writer, song domain.Song, asJSON bool) int {
+	if asJSON {
+		return printJSON(w, song)
+	}
+	fmt.Fprintf(w, "ID: %s\n", song.ID)
+	fmt.Fprintf(w, "Title: %s\n", song.Title)
+	fmt.Fprintf(w, "Artists: %s\n", strings.Join(song.Artists, ", "))
+	fmt.Fprintf(w, "URL: %s\n", songURL(song))
+	return 0
+}
+
+func resolvePlay(args []string) (string, error) {
+	query := strings.TrimSpace(strings.Join(args, " "))
+	if query == "" {
+		return "", errors.New("usage: yogo play <query|url>")
+	}
+	if strings.HasPrefix(query, "http") {
+		return query, nil
+	}
+
+	cfg, err := config.NewViperConfigService().Load()
+	if err != nil {
+		return "", fmt.Errorf("could not load configuration: %w", err)
+	}
+	ctx, stop := interruptible()
+	defer stop()
+	ytService := youtube.NewYoutubeService(cfg)
+	defer ytService.Close()
+	return firstResult(ctx, ytService, query)
+}
+
+func firstResult(ctx context.Context, ytService ports.YoutubeService, query string) (string, error) {
+	songs, err := ytService.Search(ctx, query, 1)
+	if err != nil {
+		return "", fmt.Errorf("could not search for %q: %w", query, err)
+	}
+	if len(songs) == 0 {
+		return "", fmt.Errorf("no results for %q", query)
+	}
+	return songURL(songs[0]), nil
+}
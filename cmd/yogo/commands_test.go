@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+	"yogo/internal/domain"
+	"yogo/internal/ports"
+	"yogo/internal/services/control"
+	"yogo/internal/services/events"
+	"yogo/internal/services/storage"
+
+	"github.com/stretchr/testify/require"
+)
+
+type stubYoutube struct {
+	ports.YoutubeService
+	songs []domain.Song
+	err   error
+}
+
+func (s stubYoutube) Search(ctx context.Context, query string, limit int) ([]domain.Song, error) {
+	return s.songs, s.err
+}
+
+type stubHandler struct {
+	resp ports.ControlResponse
+}
+
+func (h stubHandler) Handle(req ports.ControlRequest) ports.ControlResponse {
+	return h.resp
+}
+
+func startControl(t *testing.T, resp ports.ControlResponse) string {
+	socketPath := filepath.Join(t.TempDir(), "control.sock")
+	bus := events.NewBus()
+	t.Cleanup(bus.Close)
+	server, err := control.NewServer(socketPath, bus, stubHandler{resp: resp})
+	require.NoError(t, err)
+	go server.Serve()
+	t.Cleanup(func() { server.Close() })
+	return socketPath
+}
+
+var testSongs = []domain.Song{
+	{ID: "song1_id", Title: "Song 1", Artists: []string{"Artist 1", "Artist 2"}},
+	{ID: "song2_id", Title: "Song 2"},
+}
+
+func TestPrintSongs(t *testing.T) {
+	var out bytes.Buffer
+	require.Equal(t, 0, printSongs(&out, testSongs, false))
+	require.Equal(t, "song1_id\tSong 1 - Artist 1, Artist 2\nsong2_id\tSong 2\n", out.String())
+
+	out.Reset()
+	require.Equal(t, 0, printSongs(&out, testSongs, true))
+	var decoded []domain.Song
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	require.Equal(t, testSongs, decoded)
+
+	out.Reset()
+	require.Equal(t, 0, printSongs(&out, nil, true))
+	require.Equal(t, "[]\n", out.String(), "No results should still be a JSON array")
+}
+
+func TestPrintHistory(t *testing.T) {
+	playedAt := time.Date(2024, 5, 1, 18, 30, 0, 0, time.Local)
+	entries := []domain.HistoryEntry{{Song: testSongs[0], PlayedAt: playedAt}}
+
+	var out bytes.Buffer
+	require.Equal(t, 0, printHistory(&out, entries, false))
+	require.Equal(t, "2024-05-01 18:30\tsong1_id\tSong 1 - Artist 1, Artist 2\n", out.String())
+
+	out.Reset()
+	require.Equal(t, 0, printHistory(&out, nil, true))
+	require.Equal(t, "[]\n", out.String())
+}
+
+func TestPrintSongInfo(t *testing.T) {
+	var out bytes.Buffer
+	require.Equal(t, 0, printSongInfo(&out, testSongs[0], false))
+	require.Equal(t, "ID: song1_id\nTitle: Song 1\nArtists: Artist 1, Artist 2\nURL: https://www.youtube.com/watch?v=song1_id\n", out.String())
+
+	out.Reset()
+	require.Equal(t, 0, printSongInfo(&out, testSongs[1], true))
+	var decoded domain.Song
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	require.Equal(t, testSongs[1], decoded)
+}
+
+func TestLoadHistory(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "history.db")
+	store, err := storage.NewBboltStore(dbPath)
+	require.NoError(t, err)
+	require.NoError(t, store.AddToHistory(domain.HistoryEntry{Song: testSongs[1]}))
+	require.NoError(t, store.Close())
+
+	running := []domain.HistoryEntry{{Song: testSongs[0]}}
+	socketPath := startControl(t, ports.ControlResponse{OK: true, History: running})
+	entries, err := loadHistory(socketPath, dbPath, 10)
+	require.NoError(t, err)
+	require.Equal(t, running, entries, "A running yogo should be asked for the history")
+
+	socketPath = startControl(t, ports.ControlResponse{Error: "invalid limit"})
+	_, err = loadHistory(socketPath, dbPath, 10)
+	require.EqualError(t, err, "invalid limit", "Errors from a running yogo should not fall back to the database")
+
+	entries, err = loadHistory(filepath.Join(t.TempDir(), "missing.sock"), dbPath, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "song2_id", entries[0].Song.ID, "Without a running yogo the database should be read directly")
+}
+
+func TestResolvePlay(t *testing.T) {
+	_, err := resolvePlay([]string{" "})
+	require.Error(t, err)
+
+	url, err := resolvePlay([]string{"https://www.youtube.com/watch?v=song1_id"})
+	require.NoError(t, err)
+	require.Equal(t, "https://www.youtube.com/watch?v=song1_id", url, "URLs should be played as they are")
+
+	url, err = firstResult(context.Background(), stubYoutube{songs: testSongs}, "song")
+	require.NoError(t, err)
+	require.Equal(t, "https://www.youtube.com/watch?v=song1_id", url)
+
+	_, err = firstResult(context.Background(), stubYoutube{}, "nothing")
+	require.EqualError(t, err, `no results for "nothing"`)
+
+	_, err = firstResult(context.Background(), stubYoutube{err: errors.New("offline")}, "song")
+	require.ErrorContains(t, err, "offline")
+}
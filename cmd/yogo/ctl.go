@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"yogo/internal/format"
+	"yogo/internal/instance"
+	"yogo/internal/ports"
+	"yogo/internal/services/control"
+)
+
+const ctlUsage = "usage: yogo ctl <pause|stop|next|seek N|speed X|volume N|mute|play URL|status>"
+
+func runCtl(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, ctlUsage)
+		return 2
+	}
+
+	req := ports.ControlRequest{Command: args[0], Args: args[1:]}
+	switch req.Command {
+	case "pause", "stop", "next", "mute", "status":
+		if len(req.Args) != 0 {
+			fmt.Fprintln(os.Stderr, ctlUsage)
+			return 2
+		}
+	case "seek", "speed", "volume", "play":
+		if len(req.Args) != 1 {
+			fmt.Fprintln(os.Stderr, ctlUsage)
+			return 2
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n%s\n", req.Command, ctlUsage)
+		return 2
+	}
+
+	runtimeDir, err := instance.RuntimeDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error preparing the runtime directory: %v\n", err)
+		return 1
+	}
+
+	resp, err := control.Send(instance.ControlSocketPath(runtimeDir), req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if req.Command == "status" {
+		printStatus(resp.Status)
+	}
+	return 0
+}
+
+func printStatus(status *ports.ControlStatus) {
+	if status == nil {
+		return
+	}
+	fmt.Printf("Status: %s\n", status.Status)
+	if status.Song.ID == "" {
+		return
+	}
+	fmt.Printf("Song: %s\n", formatSong(status.Song))
+	fmt.Printf("Position: %s / %s\n", format.Duration(status.State.Position), format.Duration(status.State.Duration))
+	fmt.Printf("Speed: x%.2g\n", status.State.Speed)
+	if status.State.Muted {
+		fmt.Println("Volume: muted")
+	} else {
+		fmt.Printf("Volume: %.0f%%\n", status.State.Volume)
+	}
+}
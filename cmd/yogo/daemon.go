@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"yogo/internal/instance"
+	"yogo/internal/logger"
+	"yogo/internal/services/daemon"
+)
+
+func runDaemon() int {
+	configService, cfg, runtimeDir, ok := setup()
+	if !ok {
+		return 1
+	}
+
+	lock, err := instance.Acquire(runtimeDir)
+	if errors.Is(err, instance.ErrAlreadyRunning) {
+		fmt.Fprintf(os.Stderr, "%v, stop it before starting the daemon.\n", err)
+		return 1
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Error checking for a running instance: %v\n", err)
+		return 1
+	}
+	defer lock.Release()
+
+	svc, err := openServices(configService, cfg, runtimeDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error starting the daemon: %v\n", err)
+		return 1
+	}
+	defer svc.Close()
+
+	daemonServer, err := daemon.NewServer(instance.DaemonSocketPath(runtimeDir), svc.yt, svc.player, svc.storage, svc.queue, svc.radio, cfg.HistoryLimit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error starting the daemon: %v\n", err)
+		return 1
+	}
+	defer daemonServer.Close()
+	go daemonServer.Serve()
+
+	closeIntegrations, err := startIntegrations(cfg, runtimeDir, svc, daemonServer)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error starting the daemon: %v\n", err)
+		return 1
+	}
+	defer closeIntegrations()
+
+	logger.Log.Info().Int("pid", os.Getpid()).Msg("Daemon started")
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	sig := <-signals
+	logger.Log.Info().Str("signal", sig.String()).Msg("Daemon stopping")
+	return 0
+}
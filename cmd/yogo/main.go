@@ -1,13 +1,26 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
-	"path/filepath"
+	"yogo/internal/domain"
+	"yogo/internal/instance"
 	"yogo/internal/logger"
+	"yogo/internal/ports"
+	"yogo/internal/services/api"
 	"yogo/internal/services/config"
+	"yogo/internal/services/control"
+	"yogo/internal/services/daemon"
+	"yogo/internal/services/events"
+	"yogo/internal/services/hooks"
+	"yogo/internal/services/mpris"
+	"yogo/internal/services/notify"
 	"yogo/internal/services/player"
+	"yogo/internal/services/queue"
+	"yogo/internal/services/radio"
+	"yogo/internal/services/scrobble"
 	"yogo/internal/services/storage"
 	"yogo/internal/services/youtube"
 	"yogo/internal/ui"
@@ -15,45 +28,243 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+type services struct {
+	yt      ports.YoutubeService
+	player  ports.PlaybackService
+	storage ports.StorageService
+	queue   ports.QueueService
+	radio   ports.RadioService
+	bus     *events.Bus
+	hooks   *hooks.Runner
+}
+
+func (s *services) Close() {
+	s.hooks.Close()
+	s.yt.Close()
+	if err := s.player.Close(); err != nil {
+		logger.Log.Error().Err(err).Msg("Error closing the player service")
+	}
+	if err := s.storage.Close(); err != nil {
+		logger.Log.Error().Err(err).Msg("Error closing storage service")
+	}
+	s.bus.Close()
+}
+
 func main() {
+	os.Exit(run())
+}
+
+func run() int {
 	debug := flag.Bool("debug", false, "Enable debug logging")
 	flag.Parse()
 
 	logger.Setup(*debug)
 
+	initialURL := flag.Arg(0)
+	switch flag.Arg(0) {
+	case "ctl":
+		return runCtl(flag.Args()[1:])
+	case "daemon":
+		return runDaemon()
+	case "search":
+		return runSearch(flag.Args()[1:])
+	case "history":
+		return runHistory(flag.Args()[1:])
+	case "status":
+		return runStatus(flag.Args()[1:])
+	case "info":
+		return runInfo(flag.Args()[1:])
+	case "play":
+		url, err := resolvePlay(flag.Args()[1:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		initialURL = url
+	}
+
+	configService, cfg, runtimeDir, ok := setup()
+	if !ok {
+		return 1
+	}
+
+	lock, err := instance.Acquire(runtimeDir)
+	if errors.Is(err, instance.ErrAlreadyRunning) && initialURL != "" {
+		playRequest := ports.ControlRequest{Command: "play", Args: []string{initialURL}}
+		if _, err := control.Send(instance.ControlSocketPath(runtimeDir), playRequest); err != nil {
+			fmt.Fprintf(os.Stderr, "Error forwarding the URL to the running instance: %v\n", err)
+			return 1
+		}
+		return 0
+	} else if errors.Is(err, instance.ErrAlreadyRunning) {
+		daemonSocket := instance.DaemonSocketPath(runtimeDir)
+		if _, statErr := os.Stat(daemonSocket); statErr != nil {
+			fmt.Fprintf(os.Stderr, "%v, only one instance can run at a time.\n", err)
+			return 1
+		}
+		client, err := daemon.Dial(daemonSocket)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error attaching to the yogo daemon: %v\n", err)
+			return 1
+		}
+		return runAttached(client, configService, cfg)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Error checking for a running instance: %v\n", err)
+		return 1
+	}
+	defer lock.Release()
+
+	svc, err := openServices(configService, cfg, runtimeDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error starting yogo: %v\n", err)
+		return 1
+	}
+	defer svc.Close()
+
+	controlHandler := ui.NewProgramControlHandler()
+	model := ui.InitialModel(svc.yt, svc.player, svc.storage, svc.queue, svc.radio, configService, cfg, svc.bus)
+
+	closeIntegrations, err := startIntegrations(cfg, runtimeDir, svc, controlHandler)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error starting yogo: %v\n", err)
+		return 1
+	}
+	defer closeIntegrations()
+
+	p := tea.NewProgram(model, tea.WithAltScreen())
+	controlHandler.Attach(p)
+
+	if initialURL != "" {
+		go controlHandler.Handle(ports.ControlRequest{Command: "play", Args: []string{initialURL}})
+	}
+
+	if _, err := p.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error executing the program: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func setup() (ports.ConfigService, domain.Config, string, bool) {
 	configService := config.NewViperConfigService()
 	cfg, err := configService.Load()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
-		os.Exit(1)
+		return nil, cfg, "", false
+	}
+
+	runtimeDir, err := instance.RuntimeDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error preparing the runtime directory: %v\n", err)
+		return nil, cfg, "", false
 	}
+	return configService, cfg, runtimeDir, true
+}
 
-	ytService := youtube.NewYoutubeClient(cfg.CookiesPath)
+func openServices(configService ports.ConfigService, cfg domain.Config, runtimeDir string) (*services, error) {
+	ytService := youtube.NewYoutubeService(cfg)
+	playerService := player.NewMpvPlayer(instance.MpvSocketPath(runtimeDir), cfg)
 
-	socketPath := filepath.Join(os.TempDir(), "yogo.sock")
-	playerService := player.NewMpvPlayer(socketPath, cfg)
+	storageService, err := storage.NewBboltStore(historyPath())
+	if err != nil {
+		playerService.Close()
+		return nil, fmt.Errorf("could not open the database: %w", err)
+	}
+
+	queueService, err := queue.NewPersistentQueue(storageService)
+	if err != nil {
+		playerService.Close()
+		storageService.Close()
+		return nil, fmt.Errorf("could not load the play queue: %w", err)
+	}
+
+	bus := events.NewBus()
+	bus.ForwardPlayer(playerService)
+	config.WatchVolume(configService, bus, cfg.Playback.Volume)
+	return &services{
+		yt:      ytService,
+		player:  events.NewPlayback(bus, playerService),
+		storage: bus.Storage(storageService),
+		queue:   queueService,
+		radio:   radio.NewRadio(ytService, storageService, queueService, cfg.Playback.Autoplay),
+		bus:     bus,
+		hooks:   hooks.NewRunner(cfg.Hooks, bus),
+	}, nil
+}
+
+func startIntegrations(cfg domain.Config, runtimeDir string, svc *services, handler ports.ControlHandler) (func(), error) {
+	var closers []func() error
+	closeAll := func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i]()
+		}
+	}
 
-	configDir, _ := os.UserConfigDir()
-	dbPath := filepath.Join(configDir, "yogo", "history.db")
-	storageService, err := storage.NewBboltStore(dbPath)
+	controlServer, err := control.NewServer(instance.ControlSocketPath(runtimeDir), svc.bus, handler)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Initial database initialization failed.: %v\n", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("could not start the control socket: %w", err)
+	}
+	closers = append(closers, controlServer.Close)
+	go controlServer.Serve()
+
+	if mprisServer, err := mpris.NewServer(svc.bus, svc.player, handler); err != nil {
+		logger.Log.Warn().Err(err).Msg("MPRIS interface is not available")
+	} else {
+		closers = append(closers, mprisServer.Close)
 	}
 
-	defer func() {
-		if err := playerService.Close(); err != nil {
-			logger.Log.Error().Err(err).Msg("Error closing the player service")
+	if cfg.Notifications.Enabled {
+		if notifier, err := notify.NewNotifier(cfg.Notifications, svc.bus); err != nil {
+			logger.Log.Warn().Err(err).Msg("Desktop notifications are not available")
+		} else {
+			closers = append(closers, notifier.Close)
 		}
-		if err := storageService.Close(); err != nil {
-			logger.Log.Error().Err(err).Msg("Error closing storage service")
+	}
+
+	if cfg.Scrobbling.LastFM.Enabled || cfg.Scrobbling.ListenBrainz.Enabled {
+		scrobbler := scrobble.NewScrobbler(cfg.Scrobbling, svc.bus, svc.storage)
+		closers = append(closers, scrobbler.Close)
+	}
+
+	if cfg.API.Enabled {
+		apiServer := api.NewServer(cfg, svc.bus, svc.yt, svc.storage, svc.player, handler)
+		if err := apiServer.Start(); err != nil {
+			closeAll()
+			return nil, fmt.Errorf("could not start the HTTP API: %w", err)
 		}
-	}()
+		closers = append(closers, apiServer.Close)
+	}
 
-	p := tea.NewProgram(ui.InitialModel(ytService, playerService, storageService, cfg), tea.WithAltScreen())
+	return closeAll, nil
+}
+
+func runAttached(client *daemon.Client, configService ports.ConfigService, cfg domain.Config) int {
+	defer client.Close()
+
+	bus := events.NewBus()
+	defer bus.Close()
+	client.ReportNowPlaying(bus)
+
+	ytService := youtube.NewYoutubeService(cfg)
+	defer ytService.Close()
+	controlHandler := ui.NewProgramControlHandler()
+	model := ui.InitialModel(ytService, events.NewPlayback(bus, client.Player()), bus.Storage(client.Storage()), client.Queue(), client.Radio(), configService, cfg, bus)
+	if song, err := client.CurrentSong(); err != nil {
+		logger.Log.Warn().Err(err).Msg("Could not get the current song from the daemon")
+	} else if song.ID != "" {
+		model.Resume(song)
+	}
+
+	p := tea.NewProgram(model, tea.WithAltScreen())
+	controlHandler.Attach(p)
+	if err := client.ServeControl(controlHandler); err != nil {
+		fmt.Fprintf(os.Stderr, "Error attaching to the yogo daemon: %v\n", err)
+		return 1
+	}
 
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error executing the program: %v\n", err)
-		os.Exit(1)
+		return 1
 	}
+	return 0
 }
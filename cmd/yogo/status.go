@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+	"yogo/internal/format"
+	"yogo/internal/instance"
+	"yogo/internal/ports"
+	"yogo/internal/services/control"
+)
+
+const (
+	defaultStatusFormat = "{title} - {artist}"
+	reconnectInterval   = time.Second
+)
+
+var errStatusOutput = errors.New("could not write the status")
+
+type statusLine struct {
+	Text  string `json:"text"`
+	Class string `json:"class"`
+	ports.ControlStatus
+}
+
+func runStatus(args []string) int {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	layout := fs.String("format", defaultStatusFormat, "Output format, see the README for the placeholders")
+	follow := fs.Bool("follow", false, "Print a new line whenever the song or the pause state changes")
+	asJSON := fs.Bool("json", false, "Print JSON objects, usable as a waybar custom module")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: yogo status [--format FORMAT] [--follow] [--json]")
+		fs.PrintDefaults()
+	}
+	if positional, err := parseCommand(fs, args); err != nil {
+		return 2
+	} else if len(positional) > 0 {
+		fs.Usage()
+		return 2
+	}
+
+	runtimeDir, err := instance.RuntimeDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error preparing the runtime directory: %v\n", err)
+		return 1
+	}
+	socketPath := instance.ControlSocketPath(runtimeDir)
+
+	if !*follow {
+		status, err := fetchStatus(socketPath)
+		if err == nil {
+			err = printStatusLine(*layout, status, *asJSON)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	var last *ports.ControlStatus
+	show := func(status ports.ControlStatus) error {
+		if last != nil && status.Song.ID == last.Song.ID && status.Status == last.Status {
+			return nil
+		}
+		last = &status
+		return printStatusLine(*layout, status, *asJSON)
+	}
+	for {
+		err := control.Subscribe(socketPath, show)
+		if !errors.Is(err, errStatusOutput) {
+			err = show(ports.ControlStatus{Status: "idle"})
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		time.Sleep(reconnectInterval)
+	}
+}
+
+func fetchStatus(socketPath string) (ports.ControlStatus, error) {
+	resp, err := control.Send(socketPath, ports.ControlRequest{Command: "status"})
+	if err != nil {
+		return ports.ControlStatus{}, err
+	}
+	if resp.Status == nil {
+		return ports.ControlStatus{}, fmt.Errorf("yogo did not return a status")
+	}
+	return *resp.Status, nil
+}
+
+func printStatusLine(layout string, status ports.ControlStatus, asJSON bool) error {
+	text := format.Status(layout, status)
+	var err error
+	if asJSON {
+		err = json.NewEncoder(os.Stdout).Encode(statusLine{Text: text, Class: status.Status, ControlStatus: status})
+	} else {
+		_, err = fmt.Println(text)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", errStatusOutput, err)
+	}
+	return nil
+}
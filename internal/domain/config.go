@@ -1,13 +1,136 @@
 package domain
 
+import (
+	"net"
+	"strings"
+)
+
+type LoopMode string
+
+const (
+	LoopOff      LoopMode = "off"
+	LoopInfinite LoopMode = "infinite"
+	LoopRepeat   LoopMode = "repeat"
+)
+
+type SearchBackend string
+
+const (
+	SearchBackendYoutube SearchBackend = "youtube"
+	SearchBackendMusic   SearchBackend = "music"
+)
+
+var SearchFilters = []string{"songs", "videos", "albums", "artists", "playlists"}
+
+// MaxVolume is the loudest volume yogo allows, in percent. mpv is started with
+// a matching volume-max so values above 100 amplify.
+const MaxVolume = 130
+
 type PlaybackConfig struct {
-	Loop               bool `mapstructure:"loop"`
-	SavePositionOnQuit bool `mapstructure:"savePositionOnQuit"`
+	Loop               bool     `mapstructure:"loop"`
+	LoopMode           LoopMode `mapstructure:"loopMode"`
+	RepeatCount        int      `mapstructure:"repeatCount"`
+	SavePositionOnQuit bool     `mapstructure:"savePositionOnQuit"`
+	Volume             int      `mapstructure:"volume"`
+	Autoplay           bool     `mapstructure:"autoplay"`
+}
+
+type KeysConfig struct {
+	Quit         []string `mapstructure:"quit"`
+	Help         []string `mapstructure:"help"`
+	Search       []string `mapstructure:"search"`
+	History      []string `mapstructure:"history"`
+	Queue        []string `mapstructure:"queue"`
+	PlayPause    []string `mapstructure:"playPause"`
+	SeekForward  []string `mapstructure:"seekForward"`
+	SeekBackward []string `mapstructure:"seekBackward"`
+	SpeedUp      []string `mapstructure:"speedUp"`
+	SpeedDown    []string `mapstructure:"speedDown"`
+	SpeedReset   []string `mapstructure:"speedReset"`
+	SkipNext     []string `mapstructure:"skipNext"`
+	VolumeUp     []string `mapstructure:"volumeUp"`
+	VolumeDown   []string `mapstructure:"volumeDown"`
+	Mute         []string `mapstructure:"mute"`
+	CycleLoop    []string `mapstructure:"cycleLoop"`
+	Autoplay     []string `mapstructure:"autoplay"`
+
+	Back        []string `mapstructure:"back"`
+	SwitchFocus []string `mapstructure:"switchFocus"`
+	Select      []string `mapstructure:"select"`
+	AddToQueue  []string `mapstructure:"addToQueue"`
+	PlayNext    []string `mapstructure:"playNext"`
+	Mark        []string `mapstructure:"mark"`
+	Delete      []string `mapstructure:"delete"`
+	MoveUp      []string `mapstructure:"moveUp"`
+	MoveDown    []string `mapstructure:"moveDown"`
+	ClearQueue  []string `mapstructure:"clearQueue"`
+	NextFilter  []string `mapstructure:"nextFilter"`
+}
+
+type APIConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Address string `mapstructure:"address"`
+	Port    int    `mapstructure:"port"`
+	Token   string `mapstructure:"token"`
+}
+
+func IsLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+type NotificationsConfig struct {
+	Enabled   bool `mapstructure:"enabled"`
+	Timeout   int  `mapstructure:"timeout"`
+	Thumbnail bool `mapstructure:"thumbnail"`
+}
+
+type LastFMConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	APIKey     string `mapstructure:"apiKey"`
+	APISecret  string `mapstructure:"apiSecret"`
+	SessionKey string `mapstructure:"sessionKey"`
+	Username   string `mapstructure:"username"`
+	Password   string `mapstructure:"password"`
+	URL        string `mapstructure:"url"`
+}
+
+type ListenBrainzConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Token   string `mapstructure:"token"`
+	URL     string `mapstructure:"url"`
+}
+
+type ScrobblingConfig struct {
+	LastFM       LastFMConfig       `mapstructure:"lastfm"`
+	ListenBrainz ListenBrainzConfig `mapstructure:"listenbrainz"`
+}
+
+type HooksConfig struct {
+	Timeout        int    `mapstructure:"timeout"`
+	SongStarted    string `mapstructure:"songStarted"`
+	SongFinished   string `mapstructure:"songFinished"`
+	Paused         string `mapstructure:"paused"`
+	Resumed        string `mapstructure:"resumed"`
+	PlaybackError  string `mapstructure:"playbackError"`
+	AddedToHistory string `mapstructure:"addedToHistory"`
 }
 
 type Config struct {
-	CookiesPath  string         `mapstructure:"cookiesPath"`
-	HistoryLimit int            `mapstructure:"historyLimit"`
-	SearchLimit  int            `mapstructure:"searchLimit"`
-	Playback     PlaybackConfig `mapstructure:"playback"`
+	CookiesPath     string              `mapstructure:"cookiesPath"`
+	HistoryLimit    int                 `mapstructure:"historyLimit"`
+	SearchLimit     int                 `mapstructure:"searchLimit"`
+	SearchBackend   SearchBackend       `mapstructure:"searchBackend"`
+	SearchFilter    string              `mapstructure:"searchFilter"`
+	SearchTimeout   int                 `mapstructure:"searchTimeout"`
+	SongInfoTimeout int                 `mapstructure:"songInfoTimeout"`
+	Playback        PlaybackConfig      `mapstructure:"playback"`
+	Keys            KeysConfig          `mapstructure:"keys"`
+	API             APIConfig           `mapstructure:"api"`
+	Hooks           HooksConfig         `mapstructure:"hooks"`
+	Notifications   NotificationsConfig `mapstructure:"notifications"`
+	Scrobbling      ScrobblingConfig    `mapstructure:"scrobbling"`
 }
@@ -1,6 +1,15 @@
 package domain
 
+const (
+	PlaylistAlbum  = "album"
+	PlaylistArtist = "artist"
+)
+
 type Playlist struct {
-	ID    string
-	Title string
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Kind   string `json:"kind,omitempty"`
+	Author string `json:"author,omitempty"`
+	Count  int    `json:"count,omitempty"`
+	Songs  []Song `json:"songs,omitempty"`
 }
@@ -0,0 +1,11 @@
+package domain
+
+import "time"
+
+type Scrobble struct {
+	ID        uint64    `json:"id"`
+	Service   string    `json:"service"`
+	Song      Song      `json:"song"`
+	StartedAt time.Time `json:"startedAt"`
+	Duration  int       `json:"duration"`
+}
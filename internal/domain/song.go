@@ -3,13 +3,51 @@ package domain
 import "time"
 
 type Song struct {
-	ID      string
-	Title   string
-	Artists []string
+	ID         string   `json:"id"`
+	Title      string   `json:"title"`
+	Artists    []string `json:"artists"`
+	Album      string   `json:"album,omitempty"`
+	Duration   int      `json:"duration,omitempty"`
+	Thumbnail  string   `json:"thumbnail,omitempty"`
+	ChannelID  string   `json:"channelId,omitempty"`
+	ChannelURL string   `json:"channelUrl,omitempty"`
+	Views      int64    `json:"views,omitempty"`
+	UploadDate string   `json:"uploadDate,omitempty"`
+}
+
+func (s Song) WithMetadataFrom(other Song) Song {
+	if s.Album == "" {
+		s.Album = other.Album
+	}
+	if s.Duration == 0 {
+		s.Duration = other.Duration
+	}
+	if s.Thumbnail == "" {
+		s.Thumbnail = other.Thumbnail
+	}
+	if s.ChannelID == "" {
+		s.ChannelID = other.ChannelID
+	}
+	if s.ChannelURL == "" {
+		s.ChannelURL = other.ChannelURL
+	}
+	if s.Views == 0 {
+		s.Views = other.Views
+	}
+	if s.UploadDate == "" {
+		s.UploadDate = other.UploadDate
+	}
+	return s
 }
 
 type HistoryEntry struct {
-	Song     Song
-	PlayedAt time.Time
-	ResumeAt int
+	Song     Song      `json:"song"`
+	PlayedAt time.Time `json:"playedAt"`
+	ResumeAt int       `json:"resumeAt"`
+}
+
+type SearchPage struct {
+	Songs        []Song
+	Playlists    []Playlist
+	Continuation string
 }
@@ -0,0 +1,51 @@
+package format
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"yogo/internal/ports"
+)
+
+func Duration(seconds float64) string {
+	if seconds <= 0 {
+		return "00:00"
+	}
+	d := time.Duration(seconds) * time.Second
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	s := int(d.Seconds()) % 60
+
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d", m, s)
+}
+
+func Status(layout string, status ports.ControlStatus) string {
+	if status.Song.ID == "" {
+		return ""
+	}
+	icon := "Ⅱ"
+	if status.State.IsPlaying {
+		icon = "▶"
+	}
+	speed := status.State.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+	return strings.NewReplacer(
+		"{title}", status.Song.Title,
+		"{artist}", strings.Join(status.Song.Artists, ", "),
+		"{status}", status.Status,
+		"{icon}", icon,
+		"{position}", Duration(status.State.Position),
+		"{duration}", Duration(status.State.Duration),
+		"{volume}", strconv.Itoa(int(math.Round(status.State.Volume))),
+		"{speed}", strconv.FormatFloat(speed, 'f', -1, 64),
+		"{id}", status.Song.ID,
+		"{url}", "https://www.youtube.com/watch?v="+status.Song.ID,
+	).Replace(layout)
+}
@@ -0,0 +1,60 @@
+package format
+
+import (
+	"testing"
+	"yogo/internal/domain"
+	"yogo/internal/ports"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		seconds float64
+		want    string
+	}{
+		{0, "00:00"},
+		{-3, "00:00"},
+		{59.9, "00:59"},
+		{185, "03:05"},
+		{3725, "1:02:05"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, Duration(tt.seconds), "Duration(%v)", tt.seconds)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	playing := ports.ControlStatus{
+		Status: "playing",
+		Song:   domain.Song{ID: "song1_id", Title: "Song 1", Artists: []string{"Artist 1", "Artist 2"}},
+		State:  ports.PlayerState{IsPlaying: true, Position: 65, Duration: 200, Speed: 1.25, Volume: 79.6},
+	}
+	paused := playing
+	paused.Status = "paused"
+	paused.State.IsPlaying = false
+	paused.State.Speed = 0
+
+	tests := []struct {
+		name   string
+		layout string
+		status ports.ControlStatus
+		want   string
+	}{
+		{"title and artists", "{title} - {artist}", playing, "Song 1 - Artist 1, Artist 2"},
+		{"playing", "{icon} {status}", playing, "▶ playing"},
+		{"paused", "{icon} {status}", paused, "Ⅱ paused"},
+		{"progress", "{position}/{duration}", playing, "01:05/03:20"},
+		{"volume and speed", "{volume}% x{speed}", playing, "80% x1.25"},
+		{"unknown speed", "x{speed}", paused, "x1"},
+		{"link", "{id} {url}", playing, "song1_id https://www.youtube.com/watch?v=song1_id"},
+		{"unknown placeholder", "{album}", playing, "{album}"},
+		{"idle", "{title} - {artist}", ports.ControlStatus{Status: "idle"}, ""},
+		{"stopped", "{icon} {status}", ports.ControlStatus{Status: "idle", State: ports.PlayerState{Volume: 100}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Status(tt.layout, tt.status))
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package instance
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	dirName           = "yogo"
+	lockFileName      = "yogo.lock"
+	controlSocketName = "control.sock"
+	daemonSocketName  = "daemon.sock"
+)
+
+var ErrAlreadyRunning = errors.New("yogo is already running")
+
+type Lock struct {
+	file *os.File
+}
+
+func RuntimeDir() (string, error) {
+	base := os.Getenv("XDG_RUNTIME_DIR")
+	name := dirName
+	if base == "" {
+		base = os.TempDir()
+		name = fmt.Sprintf("%s-%d", dirName, os.Getuid())
+	}
+	dir := filepath.Join(base, name)
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("could not create runtime directory: %w", err)
+	}
+
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("runtime path %s is not a directory", dir)
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) != os.Getuid() {
+		return "", fmt.Errorf("runtime directory %s is owned by another user", dir)
+	}
+	if info.Mode().Perm() != 0700 {
+		if err := os.Chmod(dir, 0700); err != nil {
+			return "", fmt.Errorf("could not restrict runtime directory permissions: %w", err)
+		}
+	}
+	return dir, nil
+}
+
+func MpvSocketPath(dir string) string {
+	return filepath.Join(dir, fmt.Sprintf("mpv-%d.sock", os.Getpid()))
+}
+
+func Acquire(dir string) (*Lock, error) {
+	path := filepath.Join(dir, lockFileName)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open lock file: %w", err)
+	}
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		defer file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			content, _ := os.ReadFile(path)
+			if pid, err := strconv.Atoi(strings.TrimSpace(string(content))); err == nil {
+				return nil, fmt.Errorf("%w (pid %d)", ErrAlreadyRunning, pid)
+			}
+			return nil, ErrAlreadyRunning
+		}
+		return nil, fmt.Errorf("could not lock %s: %w", path, err)
+	}
+
+	if err := file.Truncate(0); err == nil {
+		file.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+	}
+	return &Lock{file: file}, nil
+}
+
+func (l *Lock) Release() error {
+	os.Truncate(l.file.Name(), 0)
+	syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+	return l.file.Close()
+}
+
+func ControlSocketPath(dir string) string {
+	return filepath.Join(dir, controlSocketName)
+}
+
+func DaemonSocketPath(dir string) string {
+	return filepath.Join(dir, daemonSocketName)
+}
@@ -0,0 +1,47 @@
+package instance
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuntimeDir(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", base)
+	require.NoError(t, os.Mkdir(filepath.Join(base, "yogo"), 0755))
+
+	dir, err := RuntimeDir()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(base, "yogo"), dir)
+
+	info, err := os.Stat(dir)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0700), info.Mode().Perm(), "The runtime directory should only be accessible by its owner")
+
+	socket := MpvSocketPath(dir)
+	require.True(t, strings.HasPrefix(socket, dir))
+	require.Contains(t, filepath.Base(socket), "mpv-")
+	require.Equal(t, filepath.Join(dir, "control.sock"), ControlSocketPath(dir))
+	require.Equal(t, filepath.Join(dir, "daemon.sock"), DaemonSocketPath(dir))
+}
+
+func TestAcquire(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := Acquire(dir)
+	require.NoError(t, err)
+
+	_, err = Acquire(dir)
+	require.ErrorIs(t, err, ErrAlreadyRunning, "A second instance should not get the lock")
+	require.ErrorContains(t, err, "pid")
+
+	require.NoError(t, lock.Release())
+
+	lock, err = Acquire(dir)
+	require.NoError(t, err, "The lock should be available again after release")
+	require.NoError(t, lock.Release())
+}
@@ -4,4 +4,6 @@ import "yogo/internal/domain"
 
 type ConfigService interface {
 	Load() (domain.Config, error)
+	SavePlayback(playback domain.PlaybackConfig) error
+	SaveVolume(volume int) error
 }
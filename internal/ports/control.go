@@ -0,0 +1,25 @@
+package ports
+
+import "yogo/internal/domain"
+
+type ControlRequest struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+}
+
+type ControlStatus struct {
+	Status string      `json:"status"`
+	Song   domain.Song `json:"song"`
+	State  PlayerState `json:"state"`
+}
+
+type ControlResponse struct {
+	OK      bool                  `json:"ok"`
+	Error   string                `json:"error,omitempty"`
+	Status  *ControlStatus        `json:"status,omitempty"`
+	History []domain.HistoryEntry `json:"history,omitempty"`
+}
+
+type ControlHandler interface {
+	Handle(req ControlRequest) ControlResponse
+}
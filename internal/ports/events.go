@@ -0,0 +1,32 @@
+package ports
+
+import "yogo/internal/domain"
+
+type Event interface {
+	isEvent()
+}
+
+type SongStartedEvent struct{ Song domain.Song }
+type SongEndedEvent struct{ Song domain.Song }
+type PlaybackStoppedEvent struct{}
+type PlayerStateChangedEvent struct{ State PlayerState }
+type HistoryChangedEvent struct {
+	Song    domain.Song
+	Removed bool
+}
+type PlaybackErrorEvent struct {
+	Song domain.Song
+	Err  error
+}
+
+func (SongStartedEvent) isEvent()        {}
+func (SongEndedEvent) isEvent()          {}
+func (PlaybackStoppedEvent) isEvent()    {}
+func (PlayerStateChangedEvent) isEvent() {}
+func (HistoryChangedEvent) isEvent()     {}
+func (PlaybackErrorEvent) isEvent()      {}
+
+type EventBus interface {
+	Publish(event Event)
+	Subscribe() <-chan Event
+}
@@ -1,7 +1,6 @@
 package ports
 
 import (
-	"time"
 	"yogo/internal/domain"
 )
 
@@ -14,19 +13,68 @@ const (
 
 type ChangeFocusMsg struct{ NewFocus FocusState }
 
-type SearchResultsMsg struct{ Songs []domain.Song }
-type SearchErrorMsg struct{ Err error }
+type SearchResultsMsg struct {
+	Query        string
+	Songs        []domain.Song
+	Playlists    []domain.Playlist
+	Continuation string
+}
+type SearchMoreResultsMsg struct {
+	Token        string
+	Songs        []domain.Song
+	Playlists    []domain.Playlist
+	Continuation string
+}
+type SearchMoreErrorMsg struct {
+	Token string
+	Err   error
+}
+type SearchErrorMsg struct {
+	Query string
+	Err   error
+}
+type SuggestionsMsg struct {
+	Query       string
+	Suggestions []string
+}
+
+type OpenPlaylistMsg struct{ Playlist domain.Playlist }
+type PlaylistLoadedMsg struct{ Playlist domain.Playlist }
+type PlaylistErrorMsg struct{ Err error }
+type PlayPlaylistMsg struct{ Songs []domain.Song }
 
 type HistoryLoadedMsg struct{ Entries []domain.HistoryEntry }
 type HistoryErrorMsg struct{ Err error }
 type DeleteFromHistoryMsg struct{ SongIDs []string }
 
-type TickMsg time.Time
+type QueueLoadedMsg struct{ Songs []domain.Song }
+type QueueSongMsg struct {
+	Song     domain.Song
+	PlayNext bool
+}
+type PlayQueueItemMsg struct{ Index int }
+type RemoveFromQueueMsg struct{ Index int }
+type MoveInQueueMsg struct{ From, To int }
+type ClearQueueMsg struct{}
+
 type PlaySongMsg struct{ Song domain.Song }
 type StreamURLFetchedMsg struct {
 	Song domain.Song
 	URL  string
 }
 type SongNowPlayingMsg struct{ Song domain.Song }
-type PlayErrorMsg struct{ Err error }
+type PlayErrorMsg struct {
+	Song domain.Song
+	Err  error
+}
+type RemotePlayErrorMsg struct{ Err error }
 type PlayerStateUpdateMsg struct{ State PlayerState }
+type PlaybackEndedMsg struct{ Err error }
+type RadioFilledMsg struct{ Seed domain.Song }
+type PlayerCrashedMsg struct{ Err error }
+type PlayerRecoveredMsg struct{}
+
+type RemoteCommandMsg struct {
+	Request ControlRequest
+	Reply   chan<- ControlResponse
+}
@@ -1,10 +1,31 @@
 package ports
 
+import "yogo/internal/domain"
+
 type PlayerState struct {
-	IsPlaying bool
-	Position  float64
-	Duration  float64
-	Speed     float64
+	IsPlaying   bool            `json:"isPlaying"`
+	Position    float64         `json:"position"`
+	Duration    float64         `json:"duration"`
+	Speed       float64         `json:"speed"`
+	Volume      float64         `json:"volume"`
+	Muted       bool            `json:"muted"`
+	Loop        domain.LoopMode `json:"loop"`
+	RepeatCount int             `json:"repeatCount"`
+}
+
+type PlayerEventType int
+
+const (
+	PlayerStateChanged PlayerEventType = iota
+	PlayerTrackEnded
+	PlayerProcessExited
+	PlayerRecovered
+)
+
+type PlayerEvent struct {
+	Type  PlayerEventType
+	State PlayerState
+	Err   error
 }
 
 type PlayerService interface {
@@ -12,8 +33,22 @@ type PlayerService interface {
 	Pause() error
 	Stop() error
 	Seek(seconds int) error
+	SeekTo(position float64) error
 	ChangeSpeed(delta float64) error
 	ResetSpeed() error
+	SetVolume(volume int) error
+	ChangeVolume(delta int) error
+	ToggleMute() error
+	SetLoop(mode domain.LoopMode, repeatCount int) error
 	GetState() (PlayerState, error)
+	Subscribe() <-chan PlayerEvent
 	Close() error
 }
+
+type PlaybackService interface {
+	PlayerService
+	PlaySong(song domain.Song, mediaURL string) error
+	SetSong(song domain.Song)
+	Resume(song domain.Song)
+	Song() domain.Song
+}
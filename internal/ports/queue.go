@@ -0,0 +1,13 @@
+package ports
+
+import "yogo/internal/domain"
+
+type QueueService interface {
+	Enqueue(song domain.Song) error
+	PlayNext(song domain.Song) error
+	Next() (domain.Song, bool, error)
+	Remove(index int) error
+	Move(from, to int) error
+	Clear() error
+	List() []domain.Song
+}
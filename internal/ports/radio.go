@@ -0,0 +1,9 @@
+package ports
+
+import "yogo/internal/domain"
+
+type RadioService interface {
+	Enabled() bool
+	SetEnabled(enabled bool) error
+	Fill(seed domain.Song) (int, error)
+}
@@ -7,5 +7,12 @@ type StorageService interface {
 	GetHistory(limit int) ([]domain.HistoryEntry, error)
 	UpdateHistoryEntryPosition(songID string, position int) error
 	DeleteFromHistory(songID string) error
+	SaveQueue(songs []domain.Song) error
+	LoadQueue() ([]domain.Song, error)
+	AddPendingScrobble(scrobble domain.Scrobble) error
+	GetPendingScrobbles(limit int) ([]domain.Scrobble, error)
+	DeletePendingScrobble(id uint64) error
+	AddSearchQuery(query string) error
+	GetSearchQueries(limit int) ([]string, error)
 	Close() error
 }
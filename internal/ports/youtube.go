@@ -1,10 +1,20 @@
 package ports
 
 import (
+	"context"
 	"yogo/internal/domain"
 )
 
 type YoutubeService interface {
-	Search(query string, limit int) ([]domain.Song, error)
-	GetSongInfo(url string) (domain.Song, error)
+	Search(ctx context.Context, query string, limit int) ([]domain.Song, error)
+	SearchPage(ctx context.Context, query, continuation string) (domain.SearchPage, error)
+	GetSongInfo(ctx context.Context, url string) (domain.Song, error)
+	GetPlaylist(url string) (domain.Playlist, error)
+	Suggest(query string) ([]string, error)
+	Close() error
+}
+
+type FilteredSearchService interface {
+	SearchFilters() []string
+	SearchPageFiltered(ctx context.Context, query, filter, continuation string) (domain.SearchPage, error)
 }
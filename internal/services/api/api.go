@@ -0,0 +1,404 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"yogo/internal/domain"
+	"yogo/internal/logger"
+	"yogo/internal/ports"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	clientBufferSize = 16
+	writeTimeout     = 5 * time.Second
+)
+
+const (
+	EventState      = "state"
+	EventNowPlaying = "nowPlaying"
+)
+
+type Event struct {
+	Type  string             `json:"type"`
+	Song  *domain.Song       `json:"song,omitempty"`
+	State *ports.PlayerState `json:"state,omitempty"`
+}
+
+type Server struct {
+	cfg            domain.Config
+	ytService      ports.YoutubeService
+	storageService ports.StorageService
+	playerService  ports.PlayerService
+	control        ports.ControlHandler
+	httpServer     *http.Server
+	upgrader       websocket.Upgrader
+
+	mu      sync.Mutex
+	song    domain.Song
+	clients map[chan Event]struct{}
+
+	done chan struct{}
+}
+
+func NewServer(cfg domain.Config, bus ports.EventBus, ytService ports.YoutubeService, storageService ports.StorageService, playerService ports.PlayerService, control ports.ControlHandler) *Server {
+	s := &Server{
+		cfg:            cfg,
+		ytService:      ytService,
+		storageService: storageService,
+		playerService:  playerService,
+		control:        control,
+		clients:        make(map[chan Event]struct{}),
+		done:           make(chan struct{}),
+	}
+	s.httpServer = &http.Server{
+		Addr:              net.JoinHostPort(cfg.API.Address, strconv.Itoa(cfg.API.Port)),
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go s.watch(bus.Subscribe())
+	return s
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/search", s.handleSearch)
+	mux.HandleFunc("GET /api/history", s.handleHistory)
+	mux.HandleFunc("GET /api/status", s.handleStatus)
+	mux.HandleFunc("POST /api/play", s.handlePlay)
+	mux.HandleFunc("POST /api/pause", s.handleControl("pause"))
+	mux.HandleFunc("POST /api/stop", s.handleControl("stop"))
+	mux.HandleFunc("POST /api/next", s.handleControl("next"))
+	mux.HandleFunc("POST /api/seek", s.handleSeek)
+	mux.HandleFunc("POST /api/speed", s.handleSpeed)
+	mux.HandleFunc("POST /api/volume", s.handleVolume)
+	mux.HandleFunc("POST /api/mute", s.handleControl("mute"))
+	mux.HandleFunc("GET /api/events", s.handleEvents)
+	return s.authorize(sameOrigin(mux))
+}
+
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("could not listen on %s: %w", s.httpServer.Addr, err)
+	}
+	logger.Log.Info().Str("address", listener.Addr().String()).Msg("HTTP API listening")
+	go func() {
+		if err := s.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Log.Error().Err(err).Msg("HTTP API stopped")
+		}
+	}()
+	return nil
+}
+
+func (s *Server) Close() error {
+	close(s.done)
+	s.mu.Lock()
+	for client := range s.clients {
+		close(client)
+		delete(s.clients, client)
+	}
+	s.mu.Unlock()
+	return s.httpServer.Close()
+}
+
+func (s *Server) setSong(song domain.Song) {
+	s.mu.Lock()
+	s.song = song
+	s.mu.Unlock()
+	s.broadcast(Event{Type: EventNowPlaying, Song: &song})
+}
+
+func (s *Server) watch(events <-chan ports.Event) {
+	for {
+		select {
+		case <-s.done:
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			switch event := event.(type) {
+			case ports.PlayerStateChangedEvent:
+				state := event.State
+				s.broadcast(Event{Type: EventState, State: &state})
+			case ports.SongStartedEvent:
+				s.setSong(event.Song)
+			case ports.PlaybackStoppedEvent:
+				s.setSong(domain.Song{})
+			}
+		}
+	}
+}
+
+func (s *Server) broadcast(event Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for client := range s.clients {
+		select {
+		case client <- event:
+		default:
+			logger.Log.Warn().Msg("WebSocket client is not keeping up, disconnecting it")
+			close(client)
+			delete(s.clients, client)
+		}
+	}
+}
+
+func (s *Server) subscribe() chan Event {
+	client := make(chan Event, clientBufferSize)
+	state, stateErr := s.playerService.GetState()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	song := s.song
+	client <- Event{Type: EventNowPlaying, Song: &song}
+	if stateErr == nil {
+		client <- Event{Type: EventState, State: &state}
+	}
+	s.clients[client] = struct{}{}
+	return client
+}
+
+func (s *Server) unsubscribe(client chan Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.clients[client]; ok {
+		close(client)
+		delete(s.clients, client)
+	}
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		writeError(w, http.StatusBadRequest, errors.New("missing q parameter"))
+		return
+	}
+	limit, err := limitParam(r, s.cfg.SearchLimit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	songs, err := s.ytService.Search(r.Context(), query, limit)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, songs)
+}
+
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	limit, err := limitParam(r, s.cfg.HistoryLimit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	entries, err := s.storageService.GetHistory(limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	resp := s.control.Handle(ports.ControlRequest{Command: "status"})
+	if !resp.OK {
+		writeError(w, http.StatusServiceUnavailable, errors.New(resp.Error))
+		return
+	}
+	writeJSON(w, http.StatusOK, resp.Status)
+}
+
+func (s *Server) handlePlay(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		URL string `json:"url"`
+		ID  string `json:"id"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	mediaURL := body.URL
+	if body.ID != "" {
+		mediaURL = "https://www.youtube.com/watch?v=" + url.QueryEscape(body.ID)
+	}
+	s.writeControl(w, ports.ControlRequest{Command: "play", Args: []string{mediaURL}})
+}
+
+func (s *Server) handleControl(command string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.writeControl(w, ports.ControlRequest{Command: command})
+	}
+}
+
+func (s *Server) handleSeek(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Seconds int `json:"seconds"`
+	}
+	if readJSON(w, r, &body) {
+		s.writeControl(w, ports.ControlRequest{Command: "seek", Args: []string{strconv.Itoa(body.Seconds)}})
+	}
+}
+
+func (s *Server) handleSpeed(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Speed float64 `json:"speed"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	speed := "reset"
+	if body.Speed > 0 {
+		speed = strconv.FormatFloat(body.Speed, 'f', -1, 64)
+	}
+	s.writeControl(w, ports.ControlRequest{Command: "speed", Args: []string{speed}})
+}
+
+func (s *Server) handleVolume(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Volume int `json:"volume"`
+	}
+	if readJSON(w, r, &body) {
+		s.writeControl(w, ports.ControlRequest{Command: "volume", Args: []string{strconv.Itoa(max(body.Volume, 0))}})
+	}
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Log.Warn().Err(err).Msg("WebSocket upgrade failed")
+		return
+	}
+	defer conn.Close()
+
+	client := s.subscribe()
+	defer s.unsubscribe(client)
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-client:
+			if !ok {
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) writeControl(w http.ResponseWriter, req ports.ControlRequest) {
+	resp := s.control.Handle(req)
+	if !resp.OK {
+		writeError(w, http.StatusBadRequest, errors.New(resp.Error))
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !allowedHost(s.cfg.API.Address, r.Host) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("unexpected host %q", r.Host))
+			return
+		}
+		token := s.cfg.API.Token
+		if token != "" && subtle.ConstantTimeCompare([]byte(requestToken(r)), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestToken also accepts the token as a query parameter because browsers
+// cannot set headers on a WebSocket upgrade.
+func requestToken(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return token
+	}
+	return r.URL.Query().Get("token")
+}
+
+func allowedHost(address, host string) bool {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	if ip := net.ParseIP(address); address == "" || ip != nil && ip.IsUnspecified() {
+		return true
+	}
+	if domain.IsLoopbackHost(address) {
+		return domain.IsLoopbackHost(host)
+	}
+	return strings.EqualFold(strings.Trim(host, "[]"), strings.Trim(address, "[]"))
+}
+
+func sameOrigin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || u.Host != r.Host {
+				writeError(w, http.StatusForbidden, errors.New("cross-origin requests are not allowed"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func limitParam(r *http.Request, fallback int) (int, error) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return fallback, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 {
+		return 0, fmt.Errorf("invalid limit %q", value)
+	}
+	return limit, nil
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return false
+	}
+	return true
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, ports.ControlResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Log.Warn().Err(err).Msg("Could not write API response")
+	}
+}
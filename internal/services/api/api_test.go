@@ -0,0 +1,239 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"yogo/internal/domain"
+	"yogo/internal/ports"
+	"yogo/internal/services/events"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+type stubYoutube struct{}
+
+func (stubYoutube) Search(ctx context.Context, query string, limit int) ([]domain.Song, error) {
+	songs := make([]domain.Song, limit)
+	for i := range songs {
+		songs[i] = domain.Song{ID: fmt.Sprintf("%s_%d", query, i)}
+	}
+	return songs, nil
+}
+
+func (stubYoutube) SearchPage(ctx context.Context, query, continuation string) (domain.SearchPage, error) {
+	return domain.SearchPage{}, nil
+}
+
+func (stubYoutube) GetPlaylist(url string) (domain.Playlist, error) {
+	return domain.Playlist{}, nil
+}
+
+func (stubYoutube) Close() error {
+	return nil
+}
+
+func (stubYoutube) Suggest(query string) ([]string, error) {
+	return nil, nil
+}
+
+func (stubYoutube) GetSongInfo(ctx context.Context, url string) (domain.Song, error) {
+	return domain.Song{}, nil
+}
+
+type stubStorage struct {
+	ports.StorageService
+}
+
+func (stubStorage) GetHistory(limit int) ([]domain.HistoryEntry, error) {
+	return []domain.HistoryEntry{{Song: domain.Song{ID: "song1_id", Title: "Song 1"}}}, nil
+}
+
+type fakePlayer struct {
+	ports.PlayerService
+	bus *events.Bus
+}
+
+func (p *fakePlayer) GetState() (ports.PlayerState, error) { return ports.PlayerState{Speed: 1}, nil }
+
+type stubControl struct {
+	mu       sync.Mutex
+	requests []ports.ControlRequest
+}
+
+func (c *stubControl) Handle(req ports.ControlRequest) ports.ControlResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, req)
+	return ports.ControlResponse{OK: true}
+}
+
+func newTestServer(t *testing.T) (*Server, *fakePlayer, *stubControl, *httptest.Server) {
+	return newTestServerWithAPI(t, domain.APIConfig{Address: "127.0.0.1"})
+}
+
+func newTestServerWithAPI(t *testing.T, api domain.APIConfig) (*Server, *fakePlayer, *stubControl, *httptest.Server) {
+	bus := events.NewBus()
+	player := &fakePlayer{bus: bus}
+	control := &stubControl{}
+	cfg := domain.Config{SearchLimit: 3, HistoryLimit: 10, API: api}
+
+	server := NewServer(cfg, bus, stubYoutube{}, stubStorage{}, player, control)
+	httpServer := httptest.NewServer(server.Handler())
+	t.Cleanup(func() {
+		httpServer.Close()
+		server.Close()
+		bus.Close()
+	})
+	return server, player, control, httpServer
+}
+
+func post(t *testing.T, url, body string) *http.Response {
+	resp, err := http.Post(url, "application/json", bytes.NewBufferString(body))
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestServer_Endpoints(t *testing.T) {
+	_, _, control, httpServer := newTestServer(t)
+
+	resp, err := http.Get(httpServer.URL + "/api/search?q=lofi&limit=2")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var songs []domain.Song
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&songs))
+	require.Equal(t, []domain.Song{{ID: "lofi_0"}, {ID: "lofi_1"}}, songs)
+
+	resp, err = http.Get(httpServer.URL + "/api/search")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(httpServer.URL + "/api/history")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var entries []domain.HistoryEntry
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&entries))
+	require.Equal(t, "Song 1", entries[0].Song.Title)
+
+	require.Equal(t, http.StatusOK, post(t, httpServer.URL+"/api/play", `{"id":"song1_id"}`).StatusCode)
+	require.Equal(t, http.StatusOK, post(t, httpServer.URL+"/api/next", "").StatusCode)
+	require.Equal(t, []ports.ControlRequest{
+		{Command: "play", Args: []string{"https://www.youtube.com/watch?v=song1_id"}},
+		{Command: "next"},
+	}, control.requests)
+
+	require.Equal(t, http.StatusOK, post(t, httpServer.URL+"/api/pause", "").StatusCode)
+	require.Equal(t, http.StatusOK, post(t, httpServer.URL+"/api/seek", `{"seconds":-10}`).StatusCode)
+	require.Equal(t, http.StatusOK, post(t, httpServer.URL+"/api/speed", `{"speed":1.5}`).StatusCode)
+	require.Equal(t, http.StatusOK, post(t, httpServer.URL+"/api/speed", `{"speed":0}`).StatusCode)
+	require.Equal(t, http.StatusOK, post(t, httpServer.URL+"/api/volume", `{"volume":40}`).StatusCode)
+	require.Equal(t, http.StatusOK, post(t, httpServer.URL+"/api/mute", "").StatusCode)
+	require.Equal(t, http.StatusBadRequest, post(t, httpServer.URL+"/api/seek", `not json`).StatusCode)
+	require.Equal(t, []ports.ControlRequest{
+		{Command: "pause"},
+		{Command: "seek", Args: []string{"-10"}},
+		{Command: "speed", Args: []string{"1.5"}},
+		{Command: "speed", Args: []string{"reset"}},
+		{Command: "volume", Args: []string{"40"}},
+		{Command: "mute"},
+	}, control.requests[2:], "Transport commands should go through the control handler like yogo ctl")
+}
+
+func TestServer_RejectsCrossOrigin(t *testing.T) {
+	_, _, control, httpServer := newTestServer(t)
+
+	req, err := http.NewRequest(http.MethodPost, httpServer.URL+"/api/pause", nil)
+	require.NoError(t, err)
+	req.Header.Set("Origin", "https://example.com")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	require.Empty(t, control.requests)
+}
+
+func TestServer_RejectsUnexpectedHost(t *testing.T) {
+	_, _, control, httpServer := newTestServer(t)
+
+	req, err := http.NewRequest(http.MethodPost, httpServer.URL+"/api/pause", nil)
+	require.NoError(t, err)
+	req.Host = "attacker.example.com"
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusForbidden, resp.StatusCode, "DNS rebinding should not reach the API")
+	require.Empty(t, control.requests)
+}
+
+func TestServer_RequiresToken(t *testing.T) {
+	_, _, _, httpServer := newTestServerWithAPI(t, domain.APIConfig{Address: "127.0.0.1", Token: "secret"})
+
+	get := func(url, authorization string) int {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+	require.Equal(t, http.StatusUnauthorized, get(httpServer.URL+"/api/status", ""))
+	require.Equal(t, http.StatusUnauthorized, get(httpServer.URL+"/api/status", "Bearer wrong"))
+	require.Equal(t, http.StatusOK, get(httpServer.URL+"/api/status", "Bearer secret"))
+
+	wsURL := "ws" + strings.TrimPrefix(httpServer.URL, "http") + "/api/events"
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.Error(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode, "The WebSocket upgrade should need the token too")
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL+"?token=secret", nil)
+	require.NoError(t, err)
+	conn.Close()
+}
+
+func TestServer_Events(t *testing.T) {
+	server, player, _, httpServer := newTestServer(t)
+	player.bus.Publish(ports.SongStartedEvent{Song: domain.Song{ID: "song1_id", Title: "Song 1"}})
+	require.Eventually(t, func() bool {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		return server.song.ID == "song1_id"
+	}, time.Second, 10*time.Millisecond)
+
+	wsURL := "ws" + strings.TrimPrefix(httpServer.URL, "http") + "/api/events"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	var event Event
+	require.NoError(t, conn.ReadJSON(&event))
+	require.Equal(t, EventNowPlaying, event.Type)
+	require.Equal(t, "Song 1", event.Song.Title)
+
+	require.NoError(t, conn.ReadJSON(&event))
+	require.Equal(t, EventState, event.Type, "The current state should be sent on connect")
+
+	player.bus.Publish(ports.PlayerStateChangedEvent{State: ports.PlayerState{IsPlaying: true, Position: 42}})
+	require.NoError(t, conn.ReadJSON(&event))
+	require.Equal(t, EventState, event.Type)
+	require.Equal(t, 42.0, event.State.Position)
+
+	player.bus.Publish(ports.PlaybackStoppedEvent{})
+	require.NoError(t, conn.ReadJSON(&event))
+	require.Equal(t, EventNowPlaying, event.Type)
+	require.Empty(t, event.Song.ID)
+}
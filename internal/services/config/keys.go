@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"yogo/internal/domain"
+
+	"github.com/spf13/viper"
+)
+
+type keyAction struct {
+	name string
+	keys []string
+}
+
+func setKeyDefaults() {
+	defaults := map[string][]string{
+		"quit":         {"q", "ctrl+c"},
+		"help":         {"?"},
+		"search":       {"s"},
+		"history":      {"h"},
+		"queue":        {"u"},
+		"playPause":    {"space"},
+		"seekForward":  {"right"},
+		"seekBackward": {"left"},
+		"speedUp":      {"]"},
+		"speedDown":    {"["},
+		"speedReset":   {`\`},
+		"skipNext":     {">"},
+		"volumeUp":     {"+", "="},
+		"volumeDown":   {"-"},
+		"mute":         {"m"},
+		"cycleLoop":    {"l"},
+		"autoplay":     {"r"},
+		"back":         {"esc"},
+		"switchFocus":  {"tab"},
+		"select":       {"enter"},
+		"addToQueue":   {"a"},
+		"playNext":     {"n"},
+		"mark":         {"x"},
+		"delete":       {"d"},
+		"moveUp":       {"K"},
+		"moveDown":     {"J"},
+		"clearQueue":   {"c"},
+		"nextFilter":   {"ctrl+f"},
+	}
+	for name, keys := range defaults {
+		viper.SetDefault("keys."+name, keys)
+	}
+}
+
+func validateKeys(keys domain.KeysConfig) error {
+	scopes := []struct {
+		name    string
+		actions []keyAction
+	}{
+		{
+			name: "player",
+			actions: []keyAction{
+				{"quit", keys.Quit},
+				{"help", keys.Help},
+				{"search", keys.Search},
+				{"history", keys.History},
+				{"queue", keys.Queue},
+				{"playPause", keys.PlayPause},
+				{"seekForward", keys.SeekForward},
+				{"seekBackward", keys.SeekBackward},
+				{"speedUp", keys.SpeedUp},
+				{"speedDown", keys.SpeedDown},
+				{"speedReset", keys.SpeedReset},
+				{"skipNext", keys.SkipNext},
+				{"volumeUp", keys.VolumeUp},
+				{"volumeDown", keys.VolumeDown},
+				{"mute", keys.Mute},
+				{"cycleLoop", keys.CycleLoop},
+				{"autoplay", keys.Autoplay},
+			},
+		},
+		{
+			name: "list",
+			actions: []keyAction{
+				{"help", keys.Help},
+				{"back", keys.Back},
+				{"switchFocus", keys.SwitchFocus},
+				{"select", keys.Select},
+				{"addToQueue", keys.AddToQueue},
+				{"playNext", keys.PlayNext},
+				{"mark", keys.Mark},
+				{"delete", keys.Delete},
+				{"moveUp", keys.MoveUp},
+				{"moveDown", keys.MoveDown},
+				{"clearQueue", keys.ClearQueue},
+				{"nextFilter", keys.NextFilter},
+			},
+		},
+	}
+
+	for _, scope := range scopes {
+		boundTo := make(map[string]string)
+		for _, action := range scope.actions {
+			for _, k := range action.keys {
+				if other, ok := boundTo[k]; ok && other != action.name {
+					return fmt.Errorf("key %q is bound to both %q and %q in the %s keys", k, other, action.name, scope.name)
+				}
+				boundTo[k] = action.name
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"testing"
+	"yogo/internal/domain"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateKeys(t *testing.T) {
+	keys := domain.KeysConfig{
+		Quit:   []string{"q"},
+		Search: []string{"s"},
+		Back:   []string{"esc"},
+		Delete: []string{"d"},
+		Mark:   []string{"x"},
+	}
+	require.NoError(t, validateKeys(keys))
+
+	keys.Select = []string{"q"}
+	require.NoError(t, validateKeys(keys), "The same key may be reused in different scopes")
+
+	keys.History = []string{"s"}
+	err := validateKeys(keys)
+	require.Error(t, err, "Two player actions must not share a key")
+	require.Contains(t, err.Error(), `"search" and "history"`)
+
+	keys.History = []string{"h"}
+	keys.Mark = []string{"d"}
+	require.Error(t, validateKeys(keys), "Two list actions must not share a key")
+}
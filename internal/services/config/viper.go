@@ -2,8 +2,12 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"yogo/internal/domain"
 	"yogo/internal/logger"
 	"yogo/internal/ports"
@@ -11,7 +15,9 @@ import (
 	"github.com/spf13/viper"
 )
 
-type ViperConfigService struct{}
+type ViperConfigService struct {
+	mu sync.Mutex
+}
 
 func NewViperConfigService() ports.ConfigService {
 	configDir, err := os.UserConfigDir()
@@ -35,8 +41,40 @@ func NewViperConfigService() ports.ConfigService {
 	viper.SetDefault("cookiesPath", "")
 	viper.SetDefault("historyLimit", 16)
 	viper.SetDefault("searchLimit", 16)
+	viper.SetDefault("searchBackend", string(domain.SearchBackendYoutube))
+	viper.SetDefault("searchFilter", "songs")
+	viper.SetDefault("searchTimeout", 15)
+	viper.SetDefault("songInfoTimeout", 30)
 	viper.SetDefault("playback.loop", true)
+	viper.SetDefault("playback.repeatCount", 3)
 	viper.SetDefault("playback.savePositionOnQuit", true)
+	viper.SetDefault("playback.volume", 100)
+	viper.SetDefault("playback.autoplay", false)
+	viper.SetDefault("api.enabled", false)
+	viper.SetDefault("api.address", "127.0.0.1")
+	viper.SetDefault("api.port", 8765)
+	viper.SetDefault("api.token", "")
+	viper.SetDefault("notifications.enabled", false)
+	viper.SetDefault("notifications.timeout", 5)
+	viper.SetDefault("notifications.thumbnail", true)
+	viper.SetDefault("scrobbling.lastfm.enabled", false)
+	viper.SetDefault("scrobbling.lastfm.apiKey", "")
+	viper.SetDefault("scrobbling.lastfm.apiSecret", "")
+	viper.SetDefault("scrobbling.lastfm.sessionKey", "")
+	viper.SetDefault("scrobbling.lastfm.username", "")
+	viper.SetDefault("scrobbling.lastfm.password", "")
+	viper.SetDefault("scrobbling.lastfm.url", "https://ws.audioscrobbler.com/2.0/")
+	viper.SetDefault("scrobbling.listenbrainz.enabled", false)
+	viper.SetDefault("scrobbling.listenbrainz.token", "")
+	viper.SetDefault("scrobbling.listenbrainz.url", "https://api.listenbrainz.org")
+	viper.SetDefault("hooks.timeout", 10)
+	viper.SetDefault("hooks.songStarted", "")
+	viper.SetDefault("hooks.songFinished", "")
+	viper.SetDefault("hooks.paused", "")
+	viper.SetDefault("hooks.resumed", "")
+	viper.SetDefault("hooks.playbackError", "")
+	viper.SetDefault("hooks.addedToHistory", "")
+	setKeyDefaults()
 
 	return &ViperConfigService{}
 }
@@ -51,6 +89,9 @@ func (s *ViperConfigService) Load() (domain.Config, error) {
 			if err := viper.SafeWriteConfig(); err != nil {
 				return cfg, err
 			}
+			if err := viper.ReadInConfig(); err != nil {
+				return cfg, err
+			}
 		} else {
 			return cfg, err
 		}
@@ -60,5 +101,142 @@ func (s *ViperConfigService) Load() (domain.Config, error) {
 		return cfg, err
 	}
 
+	if err := normalizeLoopMode(&cfg.Playback); err != nil {
+		return cfg, err
+	}
+
+	if cfg.Playback.Volume < 0 || cfg.Playback.Volume > domain.MaxVolume {
+		return cfg, fmt.Errorf("invalid playback.volume %d, expected a number between 0 and %d", cfg.Playback.Volume, domain.MaxVolume)
+	}
+
+	if err := validateSearch(cfg); err != nil {
+		return cfg, err
+	}
+
+	if err := validateKeys(cfg.Keys); err != nil {
+		return cfg, fmt.Errorf("invalid keys configuration: %w", err)
+	}
+
+	if err := validateAPI(cfg.API); err != nil {
+		return cfg, err
+	}
+
+	if cfg.Notifications.Timeout < 0 {
+		return cfg, fmt.Errorf("invalid notifications.timeout %d, expected a number of seconds, 0 for the desktop default", cfg.Notifications.Timeout)
+	}
+
+	if err := validateScrobbling(cfg.Scrobbling); err != nil {
+		return cfg, fmt.Errorf("invalid scrobbling configuration: %w", err)
+	}
+
+	if cfg.Hooks.Timeout <= 0 {
+		return cfg, fmt.Errorf("invalid hooks.timeout %d, expected a number of seconds greater than 0", cfg.Hooks.Timeout)
+	}
+
 	return cfg, nil
 }
+
+func validateSearch(cfg domain.Config) error {
+	if cfg.SearchBackend != domain.SearchBackendYoutube && cfg.SearchBackend != domain.SearchBackendMusic {
+		return fmt.Errorf("invalid searchBackend %q, expected youtube or music", cfg.SearchBackend)
+	}
+	if !slices.Contains(domain.SearchFilters, cfg.SearchFilter) {
+		return fmt.Errorf("invalid searchFilter %q, expected one of %s", cfg.SearchFilter, strings.Join(domain.SearchFilters, ", "))
+	}
+	if cfg.SearchTimeout <= 0 {
+		return fmt.Errorf("invalid searchTimeout %d, expected a number of seconds greater than 0", cfg.SearchTimeout)
+	}
+	if cfg.SongInfoTimeout <= 0 {
+		return fmt.Errorf("invalid songInfoTimeout %d, expected a number of seconds greater than 0", cfg.SongInfoTimeout)
+	}
+	return nil
+}
+
+func validateAPI(cfg domain.APIConfig) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Port < 1 || cfg.Port > 65535 {
+		return fmt.Errorf("invalid api.port %d, expected a number between 1 and 65535", cfg.Port)
+	}
+	if cfg.Token == "" && !domain.IsLoopbackHost(cfg.Address) {
+		return fmt.Errorf("invalid api.address %q, set api.token to listen on anything but a loopback address", cfg.Address)
+	}
+	return nil
+}
+
+func validateScrobbling(cfg domain.ScrobblingConfig) error {
+	if lastfm := cfg.LastFM; lastfm.Enabled {
+		if lastfm.APIKey == "" || lastfm.APISecret == "" {
+			return errors.New("lastfm needs an apiKey and an apiSecret")
+		}
+		if lastfm.SessionKey == "" && (lastfm.Username == "" || lastfm.Password == "") {
+			return errors.New("lastfm needs a sessionKey, or a username and a password")
+		}
+	}
+	if cfg.ListenBrainz.Enabled && cfg.ListenBrainz.Token == "" {
+		return errors.New("listenbrainz needs a token")
+	}
+	return nil
+}
+
+func normalizeLoopMode(playback *domain.PlaybackConfig) error {
+	switch playback.LoopMode {
+	case "":
+		playback.LoopMode = domain.LoopOff
+		if playback.Loop {
+			playback.LoopMode = domain.LoopInfinite
+		}
+	case domain.LoopOff, domain.LoopInfinite, domain.LoopRepeat:
+		playback.Loop = playback.LoopMode != domain.LoopOff
+	default:
+		return fmt.Errorf("invalid playback.loopMode %q, expected off, infinite or repeat", playback.LoopMode)
+	}
+	if playback.RepeatCount < 1 {
+		return fmt.Errorf("invalid playback.repeatCount %d, expected a positive number", playback.RepeatCount)
+	}
+	return nil
+}
+
+// SavePlayback saves the playback settings except the volume, which the
+// process that owns the player saves through SaveVolume.
+func (s *ViperConfigService) SavePlayback(playback domain.PlaybackConfig) error {
+	return s.save(map[string]any{
+		"playback.loop":               playback.LoopMode != domain.LoopOff,
+		"playback.loopMode":           playback.LoopMode,
+		"playback.repeatCount":        playback.RepeatCount,
+		"playback.savePositionOnQuit": playback.SavePositionOnQuit,
+		"playback.autoplay":           playback.Autoplay,
+	})
+}
+
+func (s *ViperConfigService) SaveVolume(volume int) error {
+	return s.save(map[string]any{"playback.volume": volume})
+}
+
+func (s *ViperConfigService) save(values map[string]any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, value := range values {
+		viper.Set(key, value)
+	}
+	path := viper.ConfigFileUsed()
+	if path == "" {
+		return errors.New("no config file was loaded")
+	}
+	return writeConfig(path, values)
+}
+
+// writeConfig updates only the given keys in the file, so the defaults
+// registered on the global viper instance never end up in the user's config.
+func writeConfig(path string, values map[string]any) error {
+	file := viper.New()
+	file.SetConfigFile(path)
+	if err := file.ReadInConfig(); err != nil {
+		return fmt.Errorf("could not read %s: %w", path, err)
+	}
+	for key, value := range values {
+		file.Set(key, value)
+	}
+	return file.WriteConfig()
+}
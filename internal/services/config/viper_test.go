@@ -0,0 +1,88 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"yogo/internal/domain"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeLoopMode(t *testing.T) {
+	legacy := domain.PlaybackConfig{Loop: true, RepeatCount: 3}
+	require.NoError(t, normalizeLoopMode(&legacy))
+	require.Equal(t, domain.LoopInfinite, legacy.LoopMode, "loop: true should keep looping forever")
+
+	legacy = domain.PlaybackConfig{Loop: false, RepeatCount: 3}
+	require.NoError(t, normalizeLoopMode(&legacy))
+	require.Equal(t, domain.LoopOff, legacy.LoopMode)
+
+	repeat := domain.PlaybackConfig{Loop: false, LoopMode: domain.LoopRepeat, RepeatCount: 2}
+	require.NoError(t, normalizeLoopMode(&repeat))
+	require.True(t, repeat.Loop, "loopMode should take precedence over loop")
+
+	require.Error(t, normalizeLoopMode(&domain.PlaybackConfig{LoopMode: "shuffle", RepeatCount: 3}))
+	require.Error(t, normalizeLoopMode(&domain.PlaybackConfig{LoopMode: domain.LoopRepeat}))
+}
+
+func TestValidateScrobbling(t *testing.T) {
+	require.NoError(t, validateScrobbling(domain.ScrobblingConfig{}), "Disabled services need no credentials")
+
+	lastfm := domain.LastFMConfig{Enabled: true, APIKey: "key", APISecret: "secret"}
+	require.Error(t, validateScrobbling(domain.ScrobblingConfig{LastFM: lastfm}))
+	lastfm.Username, lastfm.Password = "user", "password"
+	require.NoError(t, validateScrobbling(domain.ScrobblingConfig{LastFM: lastfm}))
+	lastfm.APISecret = ""
+	require.Error(t, validateScrobbling(domain.ScrobblingConfig{LastFM: lastfm}))
+
+	require.Error(t, validateScrobbling(domain.ScrobblingConfig{ListenBrainz: domain.ListenBrainzConfig{Enabled: true}}))
+}
+
+func TestValidateAPI(t *testing.T) {
+	require.NoError(t, validateAPI(domain.APIConfig{Address: "0.0.0.0"}), "A disabled API needs no checks")
+
+	api := domain.APIConfig{Enabled: true, Address: "127.0.0.1", Port: 8765}
+	require.NoError(t, validateAPI(api))
+	api.Address = "localhost"
+	require.NoError(t, validateAPI(api))
+	api.Address = "0.0.0.0"
+	require.Error(t, validateAPI(api), "Other devices should not reach the API without a token")
+	api.Token = "secret"
+	require.NoError(t, validateAPI(api))
+	api.Port = 0
+	require.Error(t, validateAPI(api))
+}
+
+func TestValidateSearch(t *testing.T) {
+	valid := domain.Config{SearchBackend: domain.SearchBackendMusic, SearchFilter: "albums", SearchTimeout: 15, SongInfoTimeout: 30}
+	require.NoError(t, validateSearch(valid))
+
+	invalid := valid
+	invalid.SearchBackend = "bing"
+	require.Error(t, validateSearch(invalid))
+
+	invalid = valid
+	invalid.SearchFilter = "podcasts"
+	require.Error(t, validateSearch(invalid))
+
+	invalid = valid
+	invalid.SearchTimeout = 0
+	require.Error(t, validateSearch(invalid))
+}
+
+func TestWriteConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte("searchLimit: 30\nplayback:\n  volume: 80\n  autoplay: false\n"), 0644))
+
+	require.NoError(t, writeConfig(path, map[string]any{"playback.autoplay": true}))
+
+	written := viper.New()
+	written.SetConfigFile(path)
+	require.NoError(t, written.ReadInConfig())
+	require.True(t, written.GetBool("playback.autoplay"))
+	require.Equal(t, 80, written.GetInt("playback.volume"))
+	require.Equal(t, 30, written.GetInt("searchLimit"))
+	require.ElementsMatch(t, []string{"searchlimit", "playback.volume", "playback.autoplay"}, written.AllKeys(), "Only keys already in the file or saved should be written")
+}
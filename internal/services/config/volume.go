@@ -0,0 +1,29 @@
+package config
+
+import (
+	"math"
+	"yogo/internal/logger"
+	"yogo/internal/ports"
+)
+
+// WatchVolume saves playback.volume whenever the player reports a new one, so
+// a volume changed from any frontend survives a restart.
+func WatchVolume(service ports.ConfigService, bus ports.EventBus, volume int) {
+	events := bus.Subscribe()
+	go func() {
+		for event := range events {
+			changed, ok := event.(ports.PlayerStateChangedEvent)
+			if !ok {
+				continue
+			}
+			current := int(math.Round(changed.State.Volume))
+			if current == volume {
+				continue
+			}
+			volume = current
+			if err := service.SaveVolume(volume); err != nil {
+				logger.Log.Error().Err(err).Int("volume", volume).Msg("Failed to save the volume")
+			}
+		}
+	}()
+}
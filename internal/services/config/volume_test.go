@@ -0,0 +1,49 @@
+package config
+
+import (
+	"sync"
+	"testing"
+	"time"
+	"yogo/internal/domain"
+	"yogo/internal/ports"
+	"yogo/internal/services/events"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeConfigService struct {
+	ports.ConfigService
+	mu    sync.Mutex
+	saved []int
+}
+
+func (s *fakeConfigService) SaveVolume(volume int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saved = append(s.saved, volume)
+	return nil
+}
+
+func (s *fakeConfigService) Saved() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int(nil), s.saved...)
+}
+
+func TestWatchVolume(t *testing.T) {
+	bus := events.NewBus()
+	defer bus.Close()
+	service := &fakeConfigService{}
+	WatchVolume(service, bus, 100)
+
+	bus.Publish(ports.PlayerStateChangedEvent{State: ports.PlayerState{Volume: 100, Position: 3}})
+	bus.Publish(ports.SongStartedEvent{Song: domain.Song{ID: "song1_id"}})
+	bus.Publish(ports.PlayerStateChangedEvent{State: ports.PlayerState{Volume: 84.6}})
+	bus.Publish(ports.PlayerStateChangedEvent{State: ports.PlayerState{Volume: 85, Position: 4}})
+	bus.Publish(ports.PlayerStateChangedEvent{State: ports.PlayerState{Volume: 60}})
+
+	require.Eventually(t, func() bool {
+		return len(service.Saved()) == 2
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, []int{85, 60}, service.Saved(), "Only volume changes should be saved")
+}
@@ -0,0 +1,183 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"yogo/internal/domain"
+	"yogo/internal/logger"
+	"yogo/internal/ports"
+)
+
+var (
+	ErrQueueEmpty = errors.New("the queue is empty")
+	errNotPlaying = errors.New("nothing is playing")
+)
+
+// Commands implements the control commands and queue advance shared by the
+// TUI and the headless daemon. Callers schedule "play" and "next" themselves
+// through Lookup and Next, since the TUI has to start playback from its
+// update loop.
+type Commands struct {
+	ytService      ports.YoutubeService
+	playerService  ports.PlaybackService
+	storageService ports.StorageService
+	queueService   ports.QueueService
+	radio          ports.RadioService
+	historyLimit   int
+}
+
+func NewCommands(ytService ports.YoutubeService, playerService ports.PlaybackService, storageService ports.StorageService, queueService ports.QueueService, radio ports.RadioService, historyLimit int) *Commands {
+	return &Commands{
+		ytService:      ytService,
+		playerService:  playerService,
+		storageService: storageService,
+		queueService:   queueService,
+		radio:          radio,
+		historyLimit:   historyLimit,
+	}
+}
+
+func Result(err error) ports.ControlResponse {
+	if err != nil {
+		return ports.ControlResponse{Error: err.Error()}
+	}
+	return ports.ControlResponse{OK: true}
+}
+
+func Arg(req ports.ControlRequest) string {
+	if len(req.Args) > 0 {
+		return req.Args[0]
+	}
+	return ""
+}
+
+// Status derives the status reported for song from the player state.
+func Status(song domain.Song, state ports.PlayerState) string {
+	if song.ID == "" {
+		return "idle"
+	}
+	if state.IsPlaying {
+		return "playing"
+	}
+	return "paused"
+}
+
+// Run handles req. current is the caller's view of what is playing; its
+// State is filled in for the "status" command.
+func (c *Commands) Run(req ports.ControlRequest, current ports.ControlStatus) ports.ControlResponse {
+	active := current.Status == "playing" || current.Status == "paused"
+	arg := Arg(req)
+
+	switch req.Command {
+	case "status":
+		state, err := c.playerService.GetState()
+		if err != nil {
+			return Result(err)
+		}
+		current.State = state
+		return ports.ControlResponse{OK: true, Status: &current}
+
+	case "history":
+		limit := c.historyLimit
+		if arg != "" {
+			var err error
+			if limit, err = strconv.Atoi(arg); err != nil || limit < 1 {
+				return Result(fmt.Errorf("invalid limit %q", arg))
+			}
+		}
+		entries, err := c.storageService.GetHistory(limit)
+		if err != nil {
+			return Result(err)
+		}
+		return ports.ControlResponse{OK: true, History: entries}
+
+	case "pause":
+		if !active {
+			return Result(errNotPlaying)
+		}
+		return Result(c.playerService.Pause())
+
+	case "stop":
+		if err := c.playerService.Stop(); err != nil {
+			return Result(err)
+		}
+		c.playerService.SetSong(domain.Song{})
+		return Result(nil)
+
+	case "seek":
+		seconds, err := strconv.Atoi(arg)
+		if err != nil {
+			return Result(fmt.Errorf("invalid seek offset %q", arg))
+		}
+		if !active {
+			return Result(errNotPlaying)
+		}
+		return Result(c.playerService.Seek(seconds))
+
+	case "speed":
+		if !active {
+			return Result(errNotPlaying)
+		}
+		if arg == "reset" {
+			return Result(c.playerService.ResetSpeed())
+		}
+		speed, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return Result(fmt.Errorf("invalid speed %q", arg))
+		}
+		if relative(arg) {
+			return Result(c.playerService.ChangeSpeed(speed))
+		}
+		state, err := c.playerService.GetState()
+		if err != nil {
+			return Result(err)
+		}
+		return Result(c.playerService.ChangeSpeed(speed - state.Speed))
+
+	case "volume":
+		volume, err := strconv.Atoi(arg)
+		if err != nil {
+			return Result(fmt.Errorf("invalid volume %q", arg))
+		}
+		if relative(arg) {
+			return Result(c.playerService.ChangeVolume(volume))
+		}
+		return Result(c.playerService.SetVolume(volume))
+
+	case "mute":
+		return Result(c.playerService.ToggleMute())
+	}
+	return Result(fmt.Errorf("unknown command %q", req.Command))
+}
+
+// Lookup resolves the song behind a "play" URL.
+func (c *Commands) Lookup(url string) (domain.Song, error) {
+	if !strings.HasPrefix(url, "http") {
+		return domain.Song{}, fmt.Errorf("invalid url %q", url)
+	}
+	return c.ytService.GetSongInfo(context.Background(), url)
+}
+
+// Next takes the next song off the queue.
+func (c *Commands) Next() (domain.Song, bool) {
+	song, ok, err := c.queueService.Next()
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("Failed to persist queue")
+	}
+	return song, ok
+}
+
+// Autoplay queues songs related to seed. The caller should check that seed
+// is still current before playing the result with Next.
+func (c *Commands) Autoplay(seed domain.Song) {
+	if _, err := c.radio.Fill(seed); err != nil {
+		logger.Log.Warn().Err(err).Str("songID", seed.ID).Msg("Autoplay found nothing to play")
+	}
+}
+
+func relative(arg string) bool {
+	return strings.HasPrefix(arg, "+") || strings.HasPrefix(arg, "-")
+}
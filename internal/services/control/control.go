@@ -0,0 +1,237 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+	"yogo/internal/domain"
+	"yogo/internal/logger"
+	"yogo/internal/ports"
+)
+
+const (
+	requestTimeout   = 30 * time.Second
+	streamBufferSize = 16
+	SubscribeCommand = "subscribe"
+)
+
+type Server struct {
+	socketPath string
+	listener   net.Listener
+	handler    ports.ControlHandler
+
+	mu      sync.Mutex
+	last    ports.ControlStatus
+	streams map[chan ports.ControlStatus]struct{}
+
+	done chan struct{}
+}
+
+func NewServer(socketPath string, bus ports.EventBus, handler ports.ControlHandler) (*Server, error) {
+	os.Remove(socketPath)
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not listen on control socket: %w", err)
+	}
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("could not restrict control socket permissions: %w", err)
+	}
+	s := &Server{
+		socketPath: socketPath,
+		listener:   listener,
+		handler:    handler,
+		last:       ports.ControlStatus{Status: "idle"},
+		streams:    make(map[chan ports.ControlStatus]struct{}),
+		done:       make(chan struct{}),
+	}
+	go s.watch(bus.Subscribe())
+	return s, nil
+}
+
+func (s *Server) Serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logger.Log.Error().Err(err).Msg("Control socket stopped accepting connections")
+			}
+			return
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	var req ports.ControlRequest
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		logger.Log.Warn().Err(err).Msg("Invalid control request")
+		return
+	}
+	logger.Log.Info().Str("command", req.Command).Strs("args", req.Args).Msg("Control request received")
+
+	if req.Command == SubscribeCommand {
+		s.stream(conn)
+		return
+	}
+
+	resp := s.handler.Handle(req)
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		logger.Log.Warn().Err(err).Msg("Could not write control response")
+	}
+}
+
+// watch derives the status from the bus, so subscribers get a line whenever
+// the song or the pause state changes without asking the handler each time.
+func (s *Server) watch(events <-chan ports.Event) {
+	status := ports.ControlStatus{Status: "idle"}
+	for {
+		select {
+		case <-s.done:
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			switch event := event.(type) {
+			case ports.SongStartedEvent:
+				status.Song = event.Song
+			case ports.PlaybackStoppedEvent:
+				status.Song = domain.Song{}
+			case ports.PlayerStateChangedEvent:
+				status.State = event.State
+			default:
+				continue
+			}
+			status.Status = Status(status.Song, status.State)
+			s.broadcast(status)
+		}
+	}
+}
+
+func (s *Server) broadcast(status ports.ControlStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status.Song.ID == s.last.Song.ID && status.Status == s.last.Status {
+		return
+	}
+	s.last = status
+	for stream := range s.streams {
+		select {
+		case stream <- status:
+		default:
+			logger.Log.Warn().Msg("Status subscriber is not keeping up, disconnecting it")
+			close(stream)
+			delete(s.streams, stream)
+		}
+	}
+}
+
+func (s *Server) stream(conn net.Conn) {
+	statuses := make(chan ports.ControlStatus, streamBufferSize)
+	s.mu.Lock()
+	statuses <- s.last
+	s.streams[statuses] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		if _, ok := s.streams[statuses]; ok {
+			close(statuses)
+			delete(s.streams, statuses)
+		}
+		s.mu.Unlock()
+	}()
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadDeadline(time.Time{})
+		io.Copy(io.Discard, conn)
+	}()
+
+	encoder := json.NewEncoder(conn)
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-closed:
+			return
+		case status, ok := <-statuses:
+			if !ok {
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(requestTimeout))
+			if err := encoder.Encode(ports.ControlResponse{OK: true, Status: &status}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) Close() error {
+	close(s.done)
+	err := s.listener.Close()
+	os.Remove(s.socketPath)
+	return err
+}
+
+func Send(socketPath string, req ports.ControlRequest) (ports.ControlResponse, error) {
+	var resp ports.ControlResponse
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		return resp, fmt.Errorf("could not connect to a running yogo: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return resp, fmt.Errorf("could not send control request: %w", err)
+	}
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return resp, fmt.Errorf("could not read control response: %w", err)
+	}
+	if !resp.OK {
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// Subscribe calls onStatus with the current status, then again whenever the
+// song or the pause state changes, until the connection to yogo drops or
+// onStatus fails.
+func Subscribe(socketPath string, onStatus func(ports.ControlStatus) error) error {
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("could not connect to a running yogo: %w", err)
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(ports.ControlRequest{Command: SubscribeCommand}); err != nil {
+		return fmt.Errorf("could not send control request: %w", err)
+	}
+	decoder := json.NewDecoder(conn)
+	for {
+		var resp ports.ControlResponse
+		if err := decoder.Decode(&resp); err != nil {
+			return fmt.Errorf("could not read control response: %w", err)
+		}
+		if !resp.OK {
+			return errors.New(resp.Error)
+		}
+		if resp.Status == nil {
+			continue
+		}
+		if err := onStatus(*resp.Status); err != nil {
+			return err
+		}
+	}
+}
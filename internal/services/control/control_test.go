@@ -0,0 +1,113 @@
+package control
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"yogo/internal/domain"
+	"yogo/internal/ports"
+	"yogo/internal/services/events"
+
+	"github.com/stretchr/testify/require"
+)
+
+type stubHandler struct {
+	requests []ports.ControlRequest
+}
+
+func (h *stubHandler) Handle(req ports.ControlRequest) ports.ControlResponse {
+	h.requests = append(h.requests, req)
+	switch req.Command {
+	case "status":
+		return ports.ControlResponse{OK: true, Status: &ports.ControlStatus{
+			Status: "playing",
+			Song:   domain.Song{ID: "song1_id", Title: "Song 1"},
+		}}
+	case "seek":
+		return ports.ControlResponse{OK: true}
+	default:
+		return ports.ControlResponse{Error: "unknown command " + req.Command}
+	}
+}
+
+func TestServer(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "control.sock")
+	handler := &stubHandler{}
+
+	bus := events.NewBus()
+	defer bus.Close()
+	server, err := NewServer(socketPath, bus, handler)
+	require.NoError(t, err)
+	go server.Serve()
+
+	info, err := os.Stat(socketPath)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	_, err = Send(socketPath, ports.ControlRequest{Command: "seek", Args: []string{"10"}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"10"}, handler.requests[0].Args)
+
+	resp, err := Send(socketPath, ports.ControlRequest{Command: "status"})
+	require.NoError(t, err)
+	require.Equal(t, "Song 1", resp.Status.Song.Title)
+
+	_, err = Send(socketPath, ports.ControlRequest{Command: "dance"})
+	require.EqualError(t, err, "unknown command dance")
+
+	require.NoError(t, server.Close())
+	_, err = Send(socketPath, ports.ControlRequest{Command: "status"})
+	require.Error(t, err, "Requests should fail once the server is closed")
+}
+
+func TestServer_Subscribe(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "control.sock")
+	bus := events.NewBus()
+	defer bus.Close()
+	server, err := NewServer(socketPath, bus, &stubHandler{})
+	require.NoError(t, err)
+	go server.Serve()
+	defer server.Close()
+
+	statuses := make(chan ports.ControlStatus, 8)
+	stop := errors.New("stop")
+	done := make(chan error, 1)
+	go func() {
+		done <- Subscribe(socketPath, func(status ports.ControlStatus) error {
+			statuses <- status
+			if status.Status == "paused" {
+				return stop
+			}
+			return nil
+		})
+	}()
+	receive := func() ports.ControlStatus {
+		select {
+		case status := <-statuses:
+			return status
+		case <-time.After(time.Second):
+			t.Fatal("Expected a status")
+			return ports.ControlStatus{}
+		}
+	}
+	require.Equal(t, "idle", receive().Status, "The current status should be sent on subscribe")
+
+	song := domain.Song{ID: "song1_id", Title: "Song 1"}
+	require.Eventually(t, func() bool {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		return len(server.streams) == 1
+	}, time.Second, 10*time.Millisecond)
+	bus.Publish(ports.PlayerStateChangedEvent{State: ports.PlayerState{IsPlaying: true}})
+	bus.Publish(ports.SongStartedEvent{Song: song})
+	status := receive()
+	require.Equal(t, "playing", status.Status)
+	require.Equal(t, song, status.Song)
+
+	bus.Publish(ports.PlayerStateChangedEvent{State: ports.PlayerState{IsPlaying: true, Position: 12}})
+	bus.Publish(ports.PlayerStateChangedEvent{State: ports.PlayerState{Position: 12}})
+	require.Equal(t, "paused", receive().Status, "Position updates alone should not be sent")
+	require.ErrorIs(t, <-done, stop)
+}
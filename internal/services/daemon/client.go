@@ -0,0 +1,322 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"yogo/internal/domain"
+	"yogo/internal/logger"
+	"yogo/internal/ports"
+)
+
+const subscriberBufferSize = 16
+
+type Client struct {
+	peer *peer
+
+	mu          sync.Mutex
+	subscribers []chan ports.PlayerEvent
+	control     ports.ControlHandler
+}
+
+func Dial(socketPath string) (*Client, error) {
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to the yogo daemon: %w", err)
+	}
+
+	c := &Client{}
+	c.peer = newPeer(conn, c.handle)
+	go func() {
+		c.peer.run()
+		c.publish(ports.PlayerEvent{Type: ports.PlayerProcessExited, Err: errConnectionClosed})
+	}()
+
+	if err := c.peer.call("session.attach", nil, nil); err != nil {
+		c.peer.close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) handle(method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "player.event":
+		var event playerEvent
+		if err := decode(params, &event); err != nil {
+			return nil, err
+		}
+		playerEvent := ports.PlayerEvent{Type: event.Type, State: event.State}
+		if event.Error != "" {
+			playerEvent.Err = errors.New(event.Error)
+		}
+		c.publish(playerEvent)
+		return nil, nil
+	case "control":
+		var req ports.ControlRequest
+		if err := decode(params, &req); err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		control := c.control
+		c.mu.Unlock()
+		if control == nil {
+			return nil, errors.New("client does not accept control requests")
+		}
+		return control.Handle(req), nil
+	}
+	return nil, fmt.Errorf("unknown method %q", method)
+}
+
+func (c *Client) publish(event ports.PlayerEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ch := range c.subscribers {
+		select {
+		case ch <- event:
+		default:
+			logger.Log.Warn().Int("type", int(event.Type)).Msg("Player subscriber is not keeping up, dropping event")
+		}
+	}
+}
+
+func (c *Client) ServeControl(handler ports.ControlHandler) error {
+	c.mu.Lock()
+	c.control = handler
+	c.mu.Unlock()
+	return c.peer.call("session.serveControl", nil, nil)
+}
+
+func (c *Client) CurrentSong() (domain.Song, error) {
+	var song domain.Song
+	err := c.peer.call("session.current", nil, &song)
+	return song, err
+}
+
+func (c *Client) ReportNowPlaying(bus ports.EventBus) {
+	events := bus.Subscribe()
+	go func() {
+		for event := range events {
+			var song domain.Song
+			switch event := event.(type) {
+			case ports.SongStartedEvent:
+				song = event.Song
+			case ports.PlaybackStoppedEvent:
+			default:
+				continue
+			}
+			if err := c.peer.call("session.nowPlaying", song, nil); err != nil {
+				logger.Log.Warn().Err(err).Msg("Could not report the current song to the daemon")
+			}
+		}
+	}()
+}
+
+func (c *Client) Player() ports.PlayerService {
+	return &remotePlayer{c}
+}
+
+func (c *Client) Storage() ports.StorageService {
+	return &remoteStorage{c}
+}
+
+func (c *Client) Queue() ports.QueueService {
+	return &remoteQueue{c}
+}
+
+func (c *Client) Radio() ports.RadioService {
+	return &remoteRadio{c}
+}
+
+func (c *Client) Close() error {
+	c.peer.close()
+	return nil
+}
+
+type remotePlayer struct {
+	c *Client
+}
+
+func (p *remotePlayer) Play(mediaURL string) error {
+	return p.c.peer.call("player.play", mediaURL, nil)
+}
+
+func (p *remotePlayer) Pause() error {
+	return p.c.peer.call("player.pause", nil, nil)
+}
+
+func (p *remotePlayer) Stop() error {
+	return p.c.peer.call("player.stop", nil, nil)
+}
+
+func (p *remotePlayer) Seek(seconds int) error {
+	return p.c.peer.call("player.seek", seconds, nil)
+}
+
+func (p *remotePlayer) SeekTo(position float64) error {
+	return p.c.peer.call("player.seekTo", position, nil)
+}
+
+func (p *remotePlayer) ChangeSpeed(delta float64) error {
+	return p.c.peer.call("player.changeSpeed", delta, nil)
+}
+
+func (p *remotePlayer) ResetSpeed() error {
+	return p.c.peer.call("player.resetSpeed", nil, nil)
+}
+
+func (p *remotePlayer) SetVolume(volume int) error {
+	return p.c.peer.call("player.setVolume", volume, nil)
+}
+
+func (p *remotePlayer) ChangeVolume(delta int) error {
+	return p.c.peer.call("player.changeVolume", delta, nil)
+}
+
+func (p *remotePlayer) ToggleMute() error {
+	return p.c.peer.call("player.toggleMute", nil, nil)
+}
+
+func (p *remotePlayer) SetLoop(mode domain.LoopMode, repeatCount int) error {
+	return p.c.peer.call("player.setLoop", loopParams{Mode: mode, RepeatCount: repeatCount}, nil)
+}
+
+func (p *remotePlayer) GetState() (ports.PlayerState, error) {
+	var state ports.PlayerState
+	err := p.c.peer.call("player.getState", nil, &state)
+	return state, err
+}
+
+func (p *remotePlayer) Subscribe() <-chan ports.PlayerEvent {
+	ch := make(chan ports.PlayerEvent, subscriberBufferSize)
+	p.c.mu.Lock()
+	p.c.subscribers = append(p.c.subscribers, ch)
+	p.c.mu.Unlock()
+	return ch
+}
+
+func (p *remotePlayer) Close() error {
+	return nil
+}
+
+type remoteStorage struct {
+	c *Client
+}
+
+func (s *remoteStorage) AddToHistory(entry domain.HistoryEntry) error {
+	return s.c.peer.call("storage.addToHistory", entry, nil)
+}
+
+func (s *remoteStorage) GetHistory(limit int) ([]domain.HistoryEntry, error) {
+	var entries []domain.HistoryEntry
+	err := s.c.peer.call("storage.getHistory", limit, &entries)
+	return entries, err
+}
+
+func (s *remoteStorage) UpdateHistoryEntryPosition(songID string, position int) error {
+	return s.c.peer.call("storage.updateHistoryEntryPosition", positionParams{SongID: songID, Position: position}, nil)
+}
+
+func (s *remoteStorage) DeleteFromHistory(songID string) error {
+	return s.c.peer.call("storage.deleteFromHistory", songID, nil)
+}
+
+func (s *remoteStorage) SaveQueue(songs []domain.Song) error {
+	return s.c.peer.call("storage.saveQueue", songs, nil)
+}
+
+func (s *remoteStorage) LoadQueue() ([]domain.Song, error) {
+	var songs []domain.Song
+	err := s.c.peer.call("storage.loadQueue", nil, &songs)
+	return songs, err
+}
+
+func (s *remoteStorage) AddPendingScrobble(scrobble domain.Scrobble) error {
+	return s.c.peer.call("storage.addPendingScrobble", scrobble, nil)
+}
+
+func (s *remoteStorage) GetPendingScrobbles(limit int) ([]domain.Scrobble, error) {
+	var scrobbles []domain.Scrobble
+	err := s.c.peer.call("storage.getPendingScrobbles", limit, &scrobbles)
+	return scrobbles, err
+}
+
+func (s *remoteStorage) DeletePendingScrobble(id uint64) error {
+	return s.c.peer.call("storage.deletePendingScrobble", id, nil)
+}
+
+func (s *remoteStorage) AddSearchQuery(query string) error {
+	return s.c.peer.call("storage.addSearchQuery", query, nil)
+}
+
+func (s *remoteStorage) GetSearchQueries(limit int) ([]string, error) {
+	var queries []string
+	err := s.c.peer.call("storage.getSearchQueries", limit, &queries)
+	return queries, err
+}
+
+func (s *remoteStorage) Close() error {
+	return nil
+}
+
+type remoteQueue struct {
+	c *Client
+}
+
+func (q *remoteQueue) Enqueue(song domain.Song) error {
+	return q.c.peer.call("queue.enqueue", song, nil)
+}
+
+func (q *remoteQueue) PlayNext(song domain.Song) error {
+	return q.c.peer.call("queue.playNext", song, nil)
+}
+
+func (q *remoteQueue) Next() (domain.Song, bool, error) {
+	var result nextResult
+	err := q.c.peer.call("queue.next", nil, &result)
+	return result.Song, result.OK, err
+}
+
+func (q *remoteQueue) Remove(index int) error {
+	return q.c.peer.call("queue.remove", index, nil)
+}
+
+func (q *remoteQueue) Move(from, to int) error {
+	return q.c.peer.call("queue.move", moveParams{From: from, To: to}, nil)
+}
+
+func (q *remoteQueue) Clear() error {
+	return q.c.peer.call("queue.clear", nil, nil)
+}
+
+func (q *remoteQueue) List() []domain.Song {
+	var songs []domain.Song
+	if err := q.c.peer.call("queue.list", nil, &songs); err != nil {
+		logger.Log.Error().Err(err).Msg("Failed to list the queue from the daemon")
+	}
+	return songs
+}
+
+type remoteRadio struct {
+	c *Client
+}
+
+func (r *remoteRadio) Enabled() bool {
+	var enabled bool
+	if err := r.c.peer.call("radio.enabled", nil, &enabled); err != nil {
+		logger.Log.Error().Err(err).Msg("Failed to get the autoplay state from the daemon")
+	}
+	return enabled
+}
+
+func (r *remoteRadio) SetEnabled(enabled bool) error {
+	return r.c.peer.call("radio.setEnabled", enabled, nil)
+}
+
+func (r *remoteRadio) Fill(seed domain.Song) (int, error) {
+	var added int
+	err := r.c.peer.call("radio.fill", seed, &added)
+	return added, err
+}
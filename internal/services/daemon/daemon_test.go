@@ -0,0 +1,247 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+	"yogo/internal/domain"
+	"yogo/internal/ports"
+	"yogo/internal/services/events"
+	"yogo/internal/services/queue"
+	"yogo/internal/services/radio"
+	"yogo/internal/services/storage"
+
+	"github.com/stretchr/testify/require"
+)
+
+type stubYoutube struct{}
+
+func (stubYoutube) Search(ctx context.Context, query string, limit int) ([]domain.Song, error) {
+	return nil, nil
+}
+
+func (stubYoutube) SearchPage(ctx context.Context, query, continuation string) (domain.SearchPage, error) {
+	return domain.SearchPage{}, nil
+}
+
+func (stubYoutube) GetPlaylist(url string) (domain.Playlist, error) {
+	return domain.Playlist{Songs: []domain.Song{{ID: "song2_id", Title: "Song 2"}, {ID: "related_id", Title: "Related"}}}, nil
+}
+
+func (stubYoutube) Close() error {
+	return nil
+}
+
+func (stubYoutube) Suggest(query string) ([]string, error) {
+	return nil, nil
+}
+
+func (stubYoutube) GetSongInfo(ctx context.Context, url string) (domain.Song, error) {
+	return domain.Song{ID: "song1_id", Title: "Song 1"}, nil
+}
+
+type fakePlayer struct {
+	ports.PlayerService
+	mu     sync.Mutex
+	calls  []string
+	events chan ports.PlayerEvent
+}
+
+func (p *fakePlayer) record(call string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, call)
+	return nil
+}
+
+func (p *fakePlayer) Calls() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.calls...)
+}
+
+func (p *fakePlayer) Play(mediaURL string) error { return p.record("play " + mediaURL) }
+func (p *fakePlayer) Seek(seconds int) error     { return p.record(fmt.Sprintf("seek %d", seconds)) }
+func (p *fakePlayer) SeekTo(position float64) error {
+	return p.record(fmt.Sprintf("seek to %g", position))
+}
+func (p *fakePlayer) SetVolume(volume int) error          { return p.record(fmt.Sprintf("volume %d", volume)) }
+func (p *fakePlayer) ChangeVolume(delta int) error        { return p.record(fmt.Sprintf("volume %+d", delta)) }
+func (p *fakePlayer) ToggleMute() error                   { return p.record("mute") }
+func (p *fakePlayer) Subscribe() <-chan ports.PlayerEvent { return p.events }
+func (p *fakePlayer) GetState() (ports.PlayerState, error) {
+	return ports.PlayerState{IsPlaying: true, Position: 42}, nil
+}
+
+type stubControl struct{}
+
+func (stubControl) Handle(req ports.ControlRequest) ports.ControlResponse {
+	return ports.ControlResponse{OK: true, Status: &ports.ControlStatus{Status: "from the client"}}
+}
+
+func newTestServer(t *testing.T) (*Server, *fakePlayer, *events.Bus, string) {
+	dir := t.TempDir()
+	store, err := storage.NewBboltStore(filepath.Join(dir, "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	queueService, err := queue.NewPersistentQueue(store)
+	require.NoError(t, err)
+
+	player := &fakePlayer{events: make(chan ports.PlayerEvent, 16)}
+	socketPath := filepath.Join(dir, "daemon.sock")
+	bus := events.NewBus()
+	t.Cleanup(bus.Close)
+	server, err := NewServer(socketPath, stubYoutube{}, events.NewPlayback(bus, player), store, queueService, radio.NewRadio(stubYoutube{}, store, queueService, false), 20)
+	require.NoError(t, err)
+	go server.Serve()
+	t.Cleanup(func() { server.Close() })
+	return server, player, bus, socketPath
+}
+
+func TestClient_Services(t *testing.T) {
+	_, player, _, socketPath := newTestServer(t)
+
+	client, err := Dial(socketPath)
+	require.NoError(t, err)
+	defer client.Close()
+
+	song := domain.Song{ID: "song1_id", Title: "Song 1", Artists: []string{"Artist 1"}}
+	require.NoError(t, client.Queue().Enqueue(song))
+	require.Equal(t, []domain.Song{song}, client.Queue().List())
+	next, ok, err := client.Queue().Next()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, song, next)
+	require.Error(t, client.Queue().Remove(3), "Errors should be passed back to the client")
+
+	require.NoError(t, client.Storage().AddToHistory(domain.HistoryEntry{Song: song}))
+	history, err := client.Storage().GetHistory(10)
+	require.NoError(t, err)
+	require.Equal(t, "Song 1", history[0].Song.Title)
+
+	require.NoError(t, client.Player().Seek(-5))
+	require.NoError(t, client.Player().SeekTo(90.5))
+	require.Equal(t, []string{"seek -5", "seek to 90.5"}, player.Calls())
+	state, err := client.Player().GetState()
+	require.NoError(t, err)
+	require.Equal(t, 42.0, state.Position)
+
+	playerEvents := client.Player().Subscribe()
+	player.events <- ports.PlayerEvent{Type: ports.PlayerProcessExited, Err: fmt.Errorf("mpv crashed")}
+	select {
+	case event := <-playerEvents:
+		require.Equal(t, ports.PlayerProcessExited, event.Type)
+		require.EqualError(t, event.Err, "mpv crashed")
+	case <-time.After(time.Second):
+		t.Fatal("Expected the player event to be forwarded")
+	}
+
+	require.False(t, client.Radio().Enabled())
+	require.NoError(t, client.Radio().SetEnabled(true))
+	require.True(t, client.Radio().Enabled())
+
+	local := events.NewBus()
+	defer local.Close()
+	client.ReportNowPlaying(local)
+	local.Publish(ports.SongStartedEvent{Song: song})
+	require.Eventually(t, func() bool {
+		current, err := client.CurrentSong()
+		return err == nil && current.ID == song.ID
+	}, time.Second, 10*time.Millisecond, "Songs started by the client should be reported to the daemon")
+}
+
+func TestClient_SingleAttachment(t *testing.T) {
+	_, _, _, socketPath := newTestServer(t)
+
+	first, err := Dial(socketPath)
+	require.NoError(t, err)
+
+	_, err = Dial(socketPath)
+	require.ErrorContains(t, err, "already attached")
+
+	require.NoError(t, first.Close())
+	require.Eventually(t, func() bool {
+		client, err := Dial(socketPath)
+		if err != nil {
+			return false
+		}
+		client.Close()
+		return true
+	}, time.Second, 10*time.Millisecond, "Another client should attach once the first one leaves")
+}
+
+func TestServer_Control(t *testing.T) {
+	server, player, _, socketPath := newTestServer(t)
+
+	resp := server.Handle(ports.ControlRequest{Command: "play", Args: []string{"https://www.youtube.com/watch?v=song1_id"}})
+	require.True(t, resp.OK, resp.Error)
+	require.Equal(t, []string{"play https://www.youtube.com/watch?v=song1_id"}, player.Calls())
+
+	resp = server.Handle(ports.ControlRequest{Command: "status"})
+	require.Equal(t, "playing", resp.Status.Status)
+	require.Equal(t, "Song 1", resp.Status.Song.Title)
+
+	require.True(t, server.Handle(ports.ControlRequest{Command: "volume", Args: []string{"40"}}).OK)
+	require.True(t, server.Handle(ports.ControlRequest{Command: "volume", Args: []string{"-5"}}).OK)
+	require.True(t, server.Handle(ports.ControlRequest{Command: "mute"}).OK)
+	require.False(t, server.Handle(ports.ControlRequest{Command: "volume", Args: []string{"loud"}}).OK)
+	require.Equal(t, []string{"volume 40", "volume -5", "mute"}, player.Calls()[1:])
+
+	resp = server.Handle(ports.ControlRequest{Command: "history"})
+	require.True(t, resp.OK, "history should fall back to the configured limit")
+	require.Len(t, resp.History, 1)
+
+	client, err := Dial(socketPath)
+	require.NoError(t, err)
+	defer client.Close()
+	require.NoError(t, client.ServeControl(stubControl{}))
+
+	resp = server.Handle(ports.ControlRequest{Command: "status"})
+	require.Equal(t, "from the client", resp.Status.Status, "An attached client should handle control requests")
+}
+
+func TestServer_AdvancesQueueWhenDetached(t *testing.T) {
+	server, player, bus, _ := newTestServer(t)
+	published := bus.Subscribe()
+	require.NoError(t, server.queueService.Enqueue(domain.Song{ID: "song2_id", Title: "Song 2"}))
+
+	player.events <- ports.PlayerEvent{Type: ports.PlayerTrackEnded}
+	require.Eventually(t, func() bool {
+		return server.playerService.Song().ID == "song2_id"
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"play https://www.youtube.com/watch?v=song2_id"}, player.Calls())
+	require.Empty(t, server.queueService.List())
+
+	player.events <- ports.PlayerEvent{Type: ports.PlayerTrackEnded}
+	require.Eventually(t, func() bool {
+		return server.playerService.Song().ID == ""
+	}, time.Second, 10*time.Millisecond, "The session should go idle once the queue is empty")
+
+	var received []ports.Event
+	for len(published) > 0 {
+		received = append(received, <-published)
+	}
+	require.Equal(t, []ports.Event{
+		ports.SongStartedEvent{Song: domain.Song{ID: "song2_id", Title: "Song 2"}},
+		ports.SongEndedEvent{Song: domain.Song{ID: "song2_id", Title: "Song 2"}},
+		ports.PlaybackStoppedEvent{},
+	}, received)
+}
+
+func TestServer_AutoplayWhenDetached(t *testing.T) {
+	server, player, _, _ := newTestServer(t)
+	require.NoError(t, server.radio.SetEnabled(true))
+	require.NoError(t, server.play(domain.Song{ID: "song2_id", Title: "Song 2"}))
+
+	player.events <- ports.PlayerEvent{Type: ports.PlayerTrackEnded}
+	require.Eventually(t, func() bool {
+		return server.playerService.Song().ID == "related_id"
+	}, time.Second, 10*time.Millisecond, "A related song should play once the queue is empty")
+	require.Equal(t, []string{
+		"play https://www.youtube.com/watch?v=song2_id",
+		"play https://www.youtube.com/watch?v=related_id",
+	}, player.Calls())
+}
@@ -0,0 +1,166 @@
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+	"yogo/internal/logger"
+)
+
+const callTimeout = 30 * time.Second
+
+var errConnectionClosed = errors.New("connection to the yogo daemon was closed")
+
+type frame struct {
+	ID     uint64          `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Reply  bool            `json:"reply,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+type handlerFunc func(method string, params json.RawMessage) (any, error)
+
+type peer struct {
+	conn    net.Conn
+	handler handlerFunc
+
+	writeMu sync.Mutex
+	enc     *json.Encoder
+
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan frame
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newPeer(conn net.Conn, handler handlerFunc) *peer {
+	return &peer{
+		conn:    conn,
+		handler: handler,
+		enc:     json.NewEncoder(conn),
+		pending: make(map[uint64]chan frame),
+		closed:  make(chan struct{}),
+	}
+}
+
+func (p *peer) run() {
+	defer p.close()
+	scanner := bufio.NewScanner(p.conn)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var f frame
+		if err := json.Unmarshal(scanner.Bytes(), &f); err != nil {
+			logger.Log.Warn().Err(err).Msg("Invalid daemon frame")
+			continue
+		}
+		switch {
+		case f.Reply:
+			p.mu.Lock()
+			ch, ok := p.pending[f.ID]
+			delete(p.pending, f.ID)
+			p.mu.Unlock()
+			if ok {
+				ch <- f
+			}
+		case f.ID == 0:
+			if _, err := p.handler(f.Method, f.Params); err != nil {
+				logger.Log.Warn().Err(err).Str("method", f.Method).Msg("Daemon notification failed")
+			}
+		default:
+			go p.reply(f)
+		}
+	}
+}
+
+func (p *peer) reply(req frame) {
+	resp := frame{ID: req.ID, Reply: true}
+	result, err := p.handler(req.Method, req.Params)
+	if err != nil {
+		resp.Error = err.Error()
+	} else if result != nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			resp.Error = err.Error()
+		} else {
+			resp.Result = data
+		}
+	}
+	if err := p.write(resp); err != nil {
+		logger.Log.Warn().Err(err).Str("method", req.Method).Msg("Could not reply to daemon request")
+	}
+}
+
+func (p *peer) write(f frame) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	return p.enc.Encode(f)
+}
+
+func (p *peer) call(method string, params any, result any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.nextID++
+	id := p.nextID
+	ch := make(chan frame, 1)
+	p.pending[id] = ch
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		delete(p.pending, id)
+		p.mu.Unlock()
+	}()
+
+	if err := p.write(frame{ID: id, Method: method, Params: data}); err != nil {
+		return fmt.Errorf("could not send %s: %w", method, err)
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != "" {
+			return errors.New(resp.Error)
+		}
+		if result != nil && resp.Result != nil {
+			return json.Unmarshal(resp.Result, result)
+		}
+		return nil
+	case <-p.closed:
+		return errConnectionClosed
+	case <-time.After(callTimeout):
+		return fmt.Errorf("timed out waiting for %s", method)
+	}
+}
+
+func (p *peer) notify(method string, params any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return p.write(frame{Method: method, Params: data})
+}
+
+func (p *peer) close() {
+	p.closeOnce.Do(func() {
+		close(p.closed)
+		p.conn.Close()
+	})
+}
+
+func decode(params json.RawMessage, v any) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+	return nil
+}
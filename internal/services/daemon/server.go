@@ -0,0 +1,467 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"yogo/internal/domain"
+	"yogo/internal/logger"
+	"yogo/internal/ports"
+	"yogo/internal/services/control"
+)
+
+var errAlreadyAttached = errors.New("another yogo is already attached to the daemon")
+
+type playerEvent struct {
+	Type  ports.PlayerEventType `json:"type"`
+	State ports.PlayerState     `json:"state"`
+	Error string                `json:"error,omitempty"`
+}
+
+type loopParams struct {
+	Mode        domain.LoopMode `json:"mode"`
+	RepeatCount int             `json:"repeatCount"`
+}
+
+type positionParams struct {
+	SongID   string `json:"songID"`
+	Position int    `json:"position"`
+}
+
+type moveParams struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+type nextResult struct {
+	Song domain.Song `json:"song"`
+	OK   bool        `json:"ok"`
+}
+
+type Server struct {
+	socketPath     string
+	listener       net.Listener
+	ytService      ports.YoutubeService
+	playerService  ports.PlaybackService
+	storageService ports.StorageService
+	queueService   ports.QueueService
+	radio          ports.RadioService
+	commands       *control.Commands
+
+	mu         sync.Mutex
+	client     *peer
+	controller bool
+
+	done chan struct{}
+}
+
+func NewServer(socketPath string, ytService ports.YoutubeService, playerService ports.PlaybackService, storageService ports.StorageService, queueService ports.QueueService, radio ports.RadioService, historyLimit int) (*Server, error) {
+	os.Remove(socketPath)
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not listen on daemon socket: %w", err)
+	}
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("could not restrict daemon socket permissions: %w", err)
+	}
+
+	s := &Server{
+		socketPath:     socketPath,
+		listener:       listener,
+		ytService:      ytService,
+		playerService:  playerService,
+		storageService: storageService,
+		queueService:   queueService,
+		radio:          radio,
+		commands:       control.NewCommands(ytService, playerService, storageService, queueService, radio, historyLimit),
+		done:           make(chan struct{}),
+	}
+	go s.watch(playerService.Subscribe())
+	return s, nil
+}
+
+func (s *Server) Serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logger.Log.Error().Err(err).Msg("Daemon socket stopped accepting connections")
+			}
+			return
+		}
+		var p *peer
+		p = newPeer(conn, func(method string, params json.RawMessage) (any, error) {
+			return s.handle(p, method, params)
+		})
+		go func() {
+			p.run()
+			s.detach(p)
+		}()
+	}
+}
+
+func (s *Server) Close() error {
+	close(s.done)
+	err := s.listener.Close()
+	s.mu.Lock()
+	if s.client != nil {
+		s.client.close()
+	}
+	s.mu.Unlock()
+	os.Remove(s.socketPath)
+	return err
+}
+
+func (s *Server) attach(p *peer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client != nil && s.client != p {
+		return errAlreadyAttached
+	}
+	s.client = p
+	logger.Log.Info().Msg("Client attached to the daemon")
+	return nil
+}
+
+func (s *Server) detach(p *peer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client == p {
+		s.client = nil
+		s.controller = false
+		logger.Log.Info().Msg("Client detached from the daemon")
+	}
+}
+
+func (s *Server) attached() *peer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.client
+}
+
+func (s *Server) watch(events <-chan ports.PlayerEvent) {
+	for {
+		select {
+		case <-s.done:
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			client := s.attached()
+			if client == nil {
+				if event.Type == ports.PlayerTrackEnded && !s.playNext() {
+					s.autoplay()
+				}
+				continue
+			}
+			frame := playerEvent{Type: event.Type, State: event.State}
+			if event.Err != nil {
+				frame.Error = event.Err.Error()
+			}
+			if err := client.notify("player.event", frame); err != nil {
+				logger.Log.Warn().Err(err).Msg("Could not forward player event")
+			}
+		}
+	}
+}
+
+func (s *Server) handle(p *peer, method string, params json.RawMessage) (any, error) {
+	if method == "session.attach" {
+		return nil, s.attach(p)
+	}
+	if s.attached() != p {
+		return nil, errors.New("client is not attached")
+	}
+
+	switch method {
+	case "session.current":
+		return s.playerService.Song(), nil
+	case "session.nowPlaying":
+		var song domain.Song
+		if err := decode(params, &song); err != nil {
+			return nil, err
+		}
+		s.playerService.SetSong(song)
+		return nil, nil
+	case "session.serveControl":
+		s.mu.Lock()
+		s.controller = true
+		s.mu.Unlock()
+		return nil, nil
+	}
+
+	switch service, _, _ := strings.Cut(method, "."); service {
+	case "player":
+		return s.handlePlayer(method, params)
+	case "storage":
+		return s.handleStorage(method, params)
+	case "queue":
+		return s.handleQueue(method, params)
+	case "radio":
+		return s.handleRadio(method, params)
+	}
+	return nil, fmt.Errorf("unknown method %q", method)
+}
+
+func (s *Server) handleRadio(method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "radio.enabled":
+		return s.radio.Enabled(), nil
+	case "radio.setEnabled":
+		var enabled bool
+		if err := decode(params, &enabled); err != nil {
+			return nil, err
+		}
+		return nil, s.radio.SetEnabled(enabled)
+	case "radio.fill":
+		var seed domain.Song
+		if err := decode(params, &seed); err != nil {
+			return nil, err
+		}
+		return s.radio.Fill(seed)
+	}
+	return nil, fmt.Errorf("unknown method %q", method)
+}
+
+func (s *Server) handlePlayer(method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "player.play":
+		var mediaURL string
+		if err := decode(params, &mediaURL); err != nil {
+			return nil, err
+		}
+		return nil, s.playerService.Play(mediaURL)
+	case "player.pause":
+		return nil, s.playerService.Pause()
+	case "player.stop":
+		return nil, s.playerService.Stop()
+	case "player.seek":
+		var seconds int
+		if err := decode(params, &seconds); err != nil {
+			return nil, err
+		}
+		return nil, s.playerService.Seek(seconds)
+	case "player.seekTo":
+		var position float64
+		if err := decode(params, &position); err != nil {
+			return nil, err
+		}
+		return nil, s.playerService.SeekTo(position)
+	case "player.changeSpeed":
+		var delta float64
+		if err := decode(params, &delta); err != nil {
+			return nil, err
+		}
+		return nil, s.playerService.ChangeSpeed(delta)
+	case "player.resetSpeed":
+		return nil, s.playerService.ResetSpeed()
+	case "player.setVolume":
+		var volume int
+		if err := decode(params, &volume); err != nil {
+			return nil, err
+		}
+		return nil, s.playerService.SetVolume(volume)
+	case "player.changeVolume":
+		var delta int
+		if err := decode(params, &delta); err != nil {
+			return nil, err
+		}
+		return nil, s.playerService.ChangeVolume(delta)
+	case "player.toggleMute":
+		return nil, s.playerService.ToggleMute()
+	case "player.setLoop":
+		var loop loopParams
+		if err := decode(params, &loop); err != nil {
+			return nil, err
+		}
+		return nil, s.playerService.SetLoop(loop.Mode, loop.RepeatCount)
+	case "player.getState":
+		return s.playerService.GetState()
+	}
+	return nil, fmt.Errorf("unknown method %q", method)
+}
+
+func (s *Server) handleStorage(method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "storage.addToHistory":
+		var entry domain.HistoryEntry
+		if err := decode(params, &entry); err != nil {
+			return nil, err
+		}
+		return nil, s.storageService.AddToHistory(entry)
+	case "storage.getHistory":
+		var limit int
+		if err := decode(params, &limit); err != nil {
+			return nil, err
+		}
+		return s.storageService.GetHistory(limit)
+	case "storage.updateHistoryEntryPosition":
+		var position positionParams
+		if err := decode(params, &position); err != nil {
+			return nil, err
+		}
+		return nil, s.storageService.UpdateHistoryEntryPosition(position.SongID, position.Position)
+	case "storage.deleteFromHistory":
+		var songID string
+		if err := decode(params, &songID); err != nil {
+			return nil, err
+		}
+		return nil, s.storageService.DeleteFromHistory(songID)
+	case "storage.saveQueue":
+		var songs []domain.Song
+		if err := decode(params, &songs); err != nil {
+			return nil, err
+		}
+		return nil, s.storageService.SaveQueue(songs)
+	case "storage.loadQueue":
+		return s.storageService.LoadQueue()
+	case "storage.addPendingScrobble":
+		var scrobble domain.Scrobble
+		if err := decode(params, &scrobble); err != nil {
+			return nil, err
+		}
+		return nil, s.storageService.AddPendingScrobble(scrobble)
+	case "storage.getPendingScrobbles":
+		var limit int
+		if err := decode(params, &limit); err != nil {
+			return nil, err
+		}
+		return s.storageService.GetPendingScrobbles(limit)
+	case "storage.deletePendingScrobble":
+		var id uint64
+		if err := decode(params, &id); err != nil {
+			return nil, err
+		}
+		return nil, s.storageService.DeletePendingScrobble(id)
+	case "storage.addSearchQuery":
+		var query string
+		if err := decode(params, &query); err != nil {
+			return nil, err
+		}
+		return nil, s.storageService.AddSearchQuery(query)
+	case "storage.getSearchQueries":
+		var limit int
+		if err := decode(params, &limit); err != nil {
+			return nil, err
+		}
+		return s.storageService.GetSearchQueries(limit)
+	}
+	return nil, fmt.Errorf("unknown method %q", method)
+}
+
+func (s *Server) handleQueue(method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "queue.enqueue", "queue.playNext":
+		var song domain.Song
+		if err := decode(params, &song); err != nil {
+			return nil, err
+		}
+		if method == "queue.playNext" {
+			return nil, s.queueService.PlayNext(song)
+		}
+		return nil, s.queueService.Enqueue(song)
+	case "queue.next":
+		song, ok, err := s.queueService.Next()
+		return nextResult{Song: song, OK: ok}, err
+	case "queue.remove":
+		var index int
+		if err := decode(params, &index); err != nil {
+			return nil, err
+		}
+		return nil, s.queueService.Remove(index)
+	case "queue.move":
+		var move moveParams
+		if err := decode(params, &move); err != nil {
+			return nil, err
+		}
+		return nil, s.queueService.Move(move.From, move.To)
+	case "queue.clear":
+		return nil, s.queueService.Clear()
+	case "queue.list":
+		return s.queueService.List(), nil
+	}
+	return nil, fmt.Errorf("unknown method %q", method)
+}
+
+func (s *Server) Handle(req ports.ControlRequest) ports.ControlResponse {
+	s.mu.Lock()
+	client, controller := s.client, s.controller
+	s.mu.Unlock()
+
+	if client != nil && controller {
+		var resp ports.ControlResponse
+		if err := client.call("control", req, &resp); err != nil {
+			return control.Result(err)
+		}
+		return resp
+	}
+	return s.handleHeadless(req)
+}
+
+func (s *Server) handleHeadless(req ports.ControlRequest) ports.ControlResponse {
+	switch req.Command {
+	case "next":
+		if !s.playNext() {
+			return control.Result(control.ErrQueueEmpty)
+		}
+		return control.Result(nil)
+
+	case "play":
+		song, err := s.commands.Lookup(control.Arg(req))
+		if err != nil {
+			return control.Result(err)
+		}
+		return control.Result(s.play(song))
+	}
+
+	song := s.playerService.Song()
+	state, err := s.playerService.GetState()
+	if err != nil {
+		return control.Result(err)
+	}
+	return s.commands.Run(req, ports.ControlStatus{Status: control.Status(song, state), Song: song})
+}
+
+func (s *Server) playNext() bool {
+	song, ok := s.commands.Next()
+	if !ok {
+		return false
+	}
+	if err := s.play(song); err != nil {
+		logger.Log.Error().Err(err).Str("songID", song.ID).Msg("Failed to play the next song in the queue")
+	}
+	return true
+}
+
+func (s *Server) autoplay() {
+	seed := s.playerService.Song()
+	if seed.ID == "" || !s.radio.Enabled() {
+		s.playerService.SetSong(domain.Song{})
+		return
+	}
+	go func() {
+		s.commands.Autoplay(seed)
+		if s.attached() != nil || s.playerService.Song().ID != seed.ID {
+			return
+		}
+		if !s.playNext() {
+			s.playerService.SetSong(domain.Song{})
+		}
+	}()
+}
+
+func (s *Server) play(song domain.Song) error {
+	if err := s.playerService.PlaySong(song, fmt.Sprintf("https://www.youtube.com/watch?v=%s", song.ID)); err != nil {
+		return err
+	}
+	if err := s.storageService.AddToHistory(domain.HistoryEntry{Song: song}); err != nil {
+		logger.Log.Error().Err(err).Str("songID", song.ID).Msg("Failed to add song to history")
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package events
+
+import (
+	"fmt"
+	"sync"
+	"yogo/internal/domain"
+	"yogo/internal/logger"
+	"yogo/internal/ports"
+)
+
+const subscriberBufferSize = 64
+
+type Bus struct {
+	mu          sync.Mutex
+	subscribers []chan ports.Event
+	closed      bool
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+func (b *Bus) Publish(event ports.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	for _, ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			logger.Log.Warn().Str("event", fmt.Sprintf("%T", event)).Msg("Event subscriber is not keeping up, dropping oldest event")
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- event:
+			default:
+			}
+		}
+	}
+}
+
+func (b *Bus) Subscribe() <-chan ports.Event {
+	ch := make(chan ports.Event, subscriberBufferSize)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return ch
+	}
+	b.subscribers = append(b.subscribers, ch)
+	return ch
+}
+
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for _, ch := range b.subscribers {
+		close(ch)
+	}
+	b.subscribers = nil
+}
+
+func (b *Bus) ForwardPlayer(player ports.PlayerService) {
+	events := player.Subscribe()
+	go func() {
+		var last ports.PlayerState
+		published := false
+		for event := range events {
+			if !published || event.State != last {
+				b.Publish(ports.PlayerStateChangedEvent{State: event.State})
+				last, published = event.State, true
+			}
+			if event.Type == ports.PlayerProcessExited && event.Err != nil {
+				b.Publish(ports.PlaybackErrorEvent{Err: event.Err})
+			}
+		}
+	}()
+}
+
+func (b *Bus) Storage(storage ports.StorageService) ports.StorageService {
+	return &publishingStorage{StorageService: storage, bus: b}
+}
+
+type publishingStorage struct {
+	ports.StorageService
+	bus *Bus
+}
+
+func (s *publishingStorage) AddToHistory(entry domain.HistoryEntry) error {
+	if err := s.StorageService.AddToHistory(entry); err != nil {
+		return err
+	}
+	s.bus.Publish(ports.HistoryChangedEvent{Song: entry.Song})
+	return nil
+}
+
+func (s *publishingStorage) DeleteFromHistory(songID string) error {
+	if err := s.StorageService.DeleteFromHistory(songID); err != nil {
+		return err
+	}
+	s.bus.Publish(ports.HistoryChangedEvent{Song: domain.Song{ID: songID}, Removed: true})
+	return nil
+}
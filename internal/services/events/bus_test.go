@@ -0,0 +1,149 @@
+package events
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"yogo/internal/domain"
+	"yogo/internal/ports"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakePlayer struct {
+	ports.PlayerService
+	events chan ports.PlayerEvent
+}
+
+func (p *fakePlayer) Subscribe() <-chan ports.PlayerEvent { return p.events }
+
+func (p *fakePlayer) Play(mediaURL string) error {
+	if mediaURL == "" {
+		return errors.New("no url")
+	}
+	return nil
+}
+
+type fakeStorage struct {
+	ports.StorageService
+	err error
+}
+
+func (s *fakeStorage) AddToHistory(entry domain.HistoryEntry) error { return s.err }
+func (s *fakeStorage) DeleteFromHistory(songID string) error        { return s.err }
+
+func receive(t *testing.T, events <-chan ports.Event) ports.Event {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("Expected an event")
+		return nil
+	}
+}
+
+func TestBus_PublishSubscribe(t *testing.T) {
+	bus := NewBus()
+	first := bus.Subscribe()
+	second := bus.Subscribe()
+
+	song := domain.Song{ID: "song1_id"}
+	bus.Publish(ports.SongStartedEvent{Song: song})
+	bus.Publish(ports.PlaybackStoppedEvent{})
+
+	for _, events := range []<-chan ports.Event{first, second} {
+		require.Equal(t, ports.SongStartedEvent{Song: song}, receive(t, events))
+		require.Equal(t, ports.PlaybackStoppedEvent{}, receive(t, events))
+	}
+
+	bus.Close()
+	_, ok := <-first
+	require.False(t, ok, "Subscriptions should be closed with the bus")
+	bus.Publish(ports.PlaybackStoppedEvent{})
+	_, ok = <-bus.Subscribe()
+	require.False(t, ok)
+}
+
+func TestBus_SlowSubscriber(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+	events := bus.Subscribe()
+
+	for i := 0; i < subscriberBufferSize+10; i++ {
+		bus.Publish(ports.PlayerStateChangedEvent{State: ports.PlayerState{Position: float64(i)}})
+	}
+
+	require.Len(t, events, subscriberBufferSize)
+	event := receive(t, events).(ports.PlayerStateChangedEvent)
+	require.Equal(t, 10.0, event.State.Position, "The oldest events should be dropped")
+}
+
+func TestBus_ForwardPlayer(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+	events := bus.Subscribe()
+	player := &fakePlayer{events: make(chan ports.PlayerEvent, 1)}
+	bus.ForwardPlayer(player)
+
+	player.events <- ports.PlayerEvent{Type: ports.PlayerStateChanged, State: ports.PlayerState{Position: 12}}
+	require.Equal(t, ports.PlayerStateChangedEvent{State: ports.PlayerState{Position: 12}}, receive(t, events))
+	player.events <- ports.PlayerEvent{Type: ports.PlayerStateChanged, State: ports.PlayerState{Position: 12}}
+	player.events <- ports.PlayerEvent{Type: ports.PlayerStateChanged, State: ports.PlayerState{Position: 13}}
+	require.Equal(t, ports.PlayerStateChangedEvent{State: ports.PlayerState{Position: 13}}, receive(t, events), "Unchanged states should not be published")
+
+	err := errors.New("mpv crashed")
+	player.events <- ports.PlayerEvent{Type: ports.PlayerProcessExited, Err: err}
+	require.IsType(t, ports.PlayerStateChangedEvent{}, receive(t, events))
+	require.Equal(t, ports.PlaybackErrorEvent{Err: err}, receive(t, events))
+}
+
+func TestBus_Storage(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+	events := bus.Subscribe()
+
+	song := domain.Song{ID: "song1_id"}
+	require.NoError(t, bus.Storage(&fakeStorage{}).AddToHistory(domain.HistoryEntry{Song: song}))
+	require.Equal(t, ports.HistoryChangedEvent{Song: song}, receive(t, events))
+	require.NoError(t, bus.Storage(&fakeStorage{}).DeleteFromHistory(song.ID))
+	require.Equal(t, ports.HistoryChangedEvent{Song: song, Removed: true}, receive(t, events))
+
+	require.Error(t, bus.Storage(&fakeStorage{err: errors.New("disk full")}).AddToHistory(domain.HistoryEntry{Song: song}))
+	require.Empty(t, events, "Failed writes should not publish anything")
+}
+
+func TestPlayback(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+	events := bus.Subscribe()
+	player := &fakePlayer{events: make(chan ports.PlayerEvent, 1)}
+	playback := NewPlayback(bus, player)
+	playerEvents := playback.Subscribe()
+
+	song := domain.Song{ID: "song1_id"}
+	require.NoError(t, playback.PlaySong(song, "https://www.youtube.com/watch?v=song1_id"))
+	require.Equal(t, ports.SongStartedEvent{Song: song}, receive(t, events))
+	require.Equal(t, song, playback.Song())
+
+	player.events <- ports.PlayerEvent{Type: ports.PlayerTrackEnded}
+	select {
+	case event := <-playerEvents:
+		require.Equal(t, ports.PlayerTrackEnded, event.Type)
+	case <-time.After(time.Second):
+		t.Fatal("Expected the player event to be relayed")
+	}
+	require.Equal(t, ports.SongEndedEvent{Song: song}, receive(t, events), "The song should end before subscribers see the track end")
+
+	require.Error(t, playback.PlaySong(domain.Song{ID: "song2_id"}, ""))
+	require.IsType(t, ports.PlaybackErrorEvent{}, receive(t, events))
+	require.Equal(t, ports.PlaybackStoppedEvent{}, receive(t, events))
+
+	playback.Resume(song)
+	require.Equal(t, song, playback.Song())
+	require.Empty(t, events, "Resuming should not publish anything")
+
+	failure := errors.New("mpv could not play the track")
+	player.events <- ports.PlayerEvent{Type: ports.PlayerTrackEnded, Err: failure}
+	<-playerEvents
+	require.Equal(t, ports.PlaybackErrorEvent{Song: song, Err: failure}, receive(t, events), "A failed track should not count as played")
+}
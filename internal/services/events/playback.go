@@ -0,0 +1,111 @@
+package events
+
+import (
+	"sync"
+	"yogo/internal/domain"
+	"yogo/internal/logger"
+	"yogo/internal/ports"
+)
+
+// Playback tracks the song the wrapped player is playing and publishes its
+// start, end and stop on the bus. A track end reaches subscribers only after
+// its SongEndedEvent went out, so they can start the next song right away.
+type Playback struct {
+	ports.PlayerService
+	bus ports.EventBus
+
+	mu          sync.Mutex
+	song        domain.Song
+	subscribers []chan ports.PlayerEvent
+	closed      bool
+}
+
+func NewPlayback(bus ports.EventBus, player ports.PlayerService) *Playback {
+	p := &Playback{PlayerService: player, bus: bus}
+	go p.relay(player.Subscribe())
+	return p
+}
+
+func (p *Playback) PlaySong(song domain.Song, mediaURL string) error {
+	if err := p.Play(mediaURL); err != nil {
+		p.bus.Publish(ports.PlaybackErrorEvent{Song: song, Err: err})
+		p.SetSong(domain.Song{})
+		return err
+	}
+	p.SetSong(song)
+	return nil
+}
+
+func (p *Playback) SetSong(song domain.Song) {
+	p.Resume(song)
+	if song.ID == "" {
+		p.bus.Publish(ports.PlaybackStoppedEvent{})
+		return
+	}
+	p.bus.Publish(ports.SongStartedEvent{Song: song})
+}
+
+func (p *Playback) Resume(song domain.Song) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.song = song
+}
+
+func (p *Playback) Song() domain.Song {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.song
+}
+
+func (p *Playback) Subscribe() <-chan ports.PlayerEvent {
+	ch := make(chan ports.PlayerEvent, subscriberBufferSize)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		close(ch)
+		return ch
+	}
+	p.subscribers = append(p.subscribers, ch)
+	return ch
+}
+
+func (p *Playback) relay(events <-chan ports.PlayerEvent) {
+	for event := range events {
+		if event.Type == ports.PlayerTrackEnded {
+			if song := p.Song(); event.Err != nil {
+				p.bus.Publish(ports.PlaybackErrorEvent{Song: song, Err: event.Err})
+			} else if song.ID != "" {
+				p.bus.Publish(ports.SongEndedEvent{Song: song})
+			}
+		}
+		p.forward(event)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for _, ch := range p.subscribers {
+		close(ch)
+	}
+	p.subscribers = nil
+}
+
+func (p *Playback) forward(event ports.PlayerEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, ch := range p.subscribers {
+		select {
+		case ch <- event:
+		default:
+			logger.Log.Warn().Int("type", int(event.Type)).Msg("Player subscriber is not keeping up, dropping oldest event")
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- event:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"yogo/internal/domain"
+	"yogo/internal/logger"
+	"yogo/internal/ports"
+)
+
+const (
+	SongStarted    = "songStarted"
+	SongFinished   = "songFinished"
+	Paused         = "paused"
+	Resumed        = "resumed"
+	PlaybackError  = "playbackError"
+	AddedToHistory = "addedToHistory"
+)
+
+const (
+	waitDelay    = time.Second
+	maxLogOutput = 512
+)
+
+type payload struct {
+	Event    string      `json:"event"`
+	Song     domain.Song `json:"song"`
+	Position float64     `json:"position"`
+	Duration float64     `json:"duration"`
+	Error    string      `json:"error,omitempty"`
+}
+
+type Runner struct {
+	commands map[string]string
+	timeout  time.Duration
+
+	mu      sync.Mutex
+	song    domain.Song
+	playing bool
+	state   ports.PlayerState
+
+	running   sync.WaitGroup
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+func NewRunner(cfg domain.HooksConfig, bus ports.EventBus) *Runner {
+	r := &Runner{
+		commands: map[string]string{
+			SongStarted:    cfg.SongStarted,
+			SongFinished:   cfg.SongFinished,
+			Paused:         cfg.Paused,
+			Resumed:        cfg.Resumed,
+			PlaybackError:  cfg.PlaybackError,
+			AddedToHistory: cfg.AddedToHistory,
+		},
+		timeout: time.Duration(cfg.Timeout) * time.Second,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go r.watch(bus.Subscribe())
+	return r
+}
+
+func (r *Runner) watch(events <-chan ports.Event) {
+	defer close(r.stopped)
+	for {
+		select {
+		case <-r.done:
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			r.handle(event)
+		}
+	}
+}
+
+func (r *Runner) handle(event ports.Event) {
+	r.mu.Lock()
+	song := r.song
+	wasPlaying := r.playing
+	switch event := event.(type) {
+	case ports.SongStartedEvent:
+		r.song = event.Song
+		r.playing = true
+	case ports.PlaybackStoppedEvent:
+		r.song = domain.Song{}
+	case ports.PlayerStateChangedEvent:
+		r.state = event.State
+		r.playing = event.State.IsPlaying
+	}
+	r.mu.Unlock()
+
+	switch event := event.(type) {
+	case ports.SongStartedEvent:
+		r.fire(SongStarted, event.Song, nil)
+	case ports.SongEndedEvent:
+		r.fire(SongFinished, event.Song, nil)
+	case ports.PlayerStateChangedEvent:
+		if song.ID == "" {
+			return
+		}
+		if wasPlaying && !event.State.IsPlaying {
+			r.fire(Paused, song, nil)
+		} else if !wasPlaying && event.State.IsPlaying {
+			r.fire(Resumed, song, nil)
+		}
+	case ports.PlaybackErrorEvent:
+		if event.Song.ID != "" {
+			song = event.Song
+		}
+		r.fire(PlaybackError, song, event.Err)
+	case ports.HistoryChangedEvent:
+		if !event.Removed {
+			r.fire(AddedToHistory, event.Song, nil)
+		}
+	}
+}
+
+func (r *Runner) fire(event string, song domain.Song, err error) {
+	command := strings.TrimSpace(r.commands[event])
+	if command == "" {
+		return
+	}
+
+	r.mu.Lock()
+	data := payload{Event: event, Song: song, Position: r.state.Position, Duration: r.state.Duration}
+	r.mu.Unlock()
+	if err != nil {
+		data.Error = err.Error()
+	}
+
+	r.running.Add(1)
+	go func() {
+		defer r.running.Done()
+		r.run(command, data)
+	}()
+}
+
+func (r *Runner) run(command string, data payload) {
+	input, err := json.Marshal(data)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("event", data.Event).Msg("Could not encode the hook payload")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(os.Environ(), environment(data)...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = waitDelay
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	start := time.Now()
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", r.timeout)
+	}
+	if err != nil {
+		logger.Log.Error().Err(err).
+			Str("event", data.Event).
+			Str("command", command).
+			Str("output", truncate(output.String())).
+			Msg("Hook failed")
+		return
+	}
+	logger.Log.Debug().Str("event", data.Event).Dur("took", time.Since(start)).Msg("Hook finished")
+}
+
+func environment(data payload) []string {
+	env := []string{
+		"YOGO_EVENT=" + data.Event,
+		"YOGO_SONG_ID=" + data.Song.ID,
+		"YOGO_SONG_TITLE=" + data.Song.Title,
+		"YOGO_SONG_ARTISTS=" + strings.Join(data.Song.Artists, ", "),
+		"YOGO_POSITION=" + strconv.FormatFloat(data.Position, 'f', 0, 64),
+		"YOGO_DURATION=" + strconv.FormatFloat(data.Duration, 'f', 0, 64),
+	}
+	if data.Song.ID != "" {
+		env = append(env, "YOGO_SONG_URL=https://www.youtube.com/watch?v="+data.Song.ID)
+	}
+	if data.Error != "" {
+		env = append(env, "YOGO_ERROR="+data.Error)
+	}
+	return env
+}
+
+func truncate(output string) string {
+	output = strings.TrimSpace(output)
+	if len(output) > maxLogOutput {
+		return output[:maxLogOutput] + "…"
+	}
+	return output
+}
+
+func (r *Runner) Close() error {
+	r.closeOnce.Do(func() { close(r.done) })
+	<-r.stopped
+	r.running.Wait()
+	return nil
+}
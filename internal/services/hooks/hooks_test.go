@@ -0,0 +1,133 @@
+package hooks
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"yogo/internal/domain"
+	"yogo/internal/ports"
+	"yogo/internal/services/events"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRunner(t *testing.T, cfg domain.HooksConfig) (*Runner, *events.Bus) {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 5
+	}
+	bus := events.NewBus()
+	runner := NewRunner(cfg, bus)
+	t.Cleanup(func() {
+		runner.Close()
+		bus.Close()
+	})
+	return runner, bus
+}
+
+func readOutput(t *testing.T, path string) string {
+	var output []byte
+	require.Eventually(t, func() bool {
+		data, err := os.ReadFile(path)
+		if err != nil || !strings.HasSuffix(string(data), "\n") {
+			return false
+		}
+		output = data
+		return true
+	}, 2*time.Second, 10*time.Millisecond, "The hook should have run")
+	return strings.TrimSpace(string(output))
+}
+
+func TestRunner_SongStarted(t *testing.T) {
+	dir := t.TempDir()
+	_, bus := newTestRunner(t, domain.HooksConfig{
+		SongStarted: `cat > "` + filepath.Join(dir, "stdin") + `"; echo >> "` + filepath.Join(dir, "stdin") + `"; echo "$YOGO_EVENT|$YOGO_SONG_ID|$YOGO_SONG_TITLE|$YOGO_SONG_ARTISTS|$YOGO_DURATION" > "` + filepath.Join(dir, "env") + `"`,
+	})
+	bus.Publish(ports.PlayerStateChangedEvent{State: ports.PlayerState{Duration: 184.4}})
+	bus.Publish(ports.SongStartedEvent{Song: domain.Song{ID: "song1_id", Title: "Song 1", Artists: []string{"Artist 1", "Artist 2"}}})
+
+	require.Equal(t, "songStarted|song1_id|Song 1|Artist 1, Artist 2|184", readOutput(t, filepath.Join(dir, "env")))
+
+	var data payload
+	require.NoError(t, json.Unmarshal([]byte(readOutput(t, filepath.Join(dir, "stdin"))), &data))
+	require.Equal(t, SongStarted, data.Event)
+	require.Equal(t, "Song 1", data.Song.Title)
+	require.Equal(t, 184.4, data.Duration)
+}
+
+func TestRunner_PlayerEvents(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "events")
+	record := `echo "$YOGO_EVENT $YOGO_ERROR" >> "` + log + `"`
+	_, bus := newTestRunner(t, domain.HooksConfig{
+		Paused:        record,
+		Resumed:       record,
+		SongFinished:  record,
+		PlaybackError: record,
+	})
+
+	song := domain.Song{ID: "song1_id"}
+	published := []ports.Event{
+		ports.PlayerStateChangedEvent{State: ports.PlayerState{IsPlaying: false}},
+		ports.SongStartedEvent{Song: song},
+		ports.PlayerStateChangedEvent{State: ports.PlayerState{IsPlaying: true, Position: 1}},
+		ports.PlayerStateChangedEvent{State: ports.PlayerState{IsPlaying: false}},
+		ports.PlayerStateChangedEvent{State: ports.PlayerState{IsPlaying: true}},
+		ports.SongEndedEvent{Song: song},
+		ports.PlaybackErrorEvent{Err: errors.New("mpv crashed")},
+	}
+	for _, event := range published {
+		bus.Publish(event)
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	require.Eventually(t, func() bool {
+		data, _ := os.ReadFile(log)
+		return strings.Count(string(data), "\n") == 4
+	}, 2*time.Second, 10*time.Millisecond)
+	data, err := os.ReadFile(log)
+	require.NoError(t, err)
+	require.Equal(t, []string{"paused ", "resumed ", "songFinished ", "playbackError mpv crashed"}, strings.Split(strings.TrimSpace(string(data)), "\n"))
+}
+
+func TestRunner_AddedToHistory(t *testing.T) {
+	dir := t.TempDir()
+	_, bus := newTestRunner(t, domain.HooksConfig{
+		AddedToHistory: `echo "$YOGO_SONG_ID" >> "` + filepath.Join(dir, "history") + `"`,
+	})
+
+	bus.Publish(ports.HistoryChangedEvent{Song: domain.Song{ID: "removed_id"}, Removed: true})
+	bus.Publish(ports.HistoryChangedEvent{Song: domain.Song{ID: "song1_id"}})
+
+	require.Equal(t, "song1_id", readOutput(t, filepath.Join(dir, "history")), "Only added entries should run the hook")
+}
+
+func TestRunner_Timeout(t *testing.T) {
+	runner, bus := newTestRunner(t, domain.HooksConfig{Timeout: 1, SongStarted: "exec sleep 30"})
+
+	bus.Publish(ports.SongStartedEvent{Song: domain.Song{ID: "song1_id"}})
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+	require.NoError(t, runner.Close())
+	require.Less(t, time.Since(start), 5*time.Second, "Slow hooks should be killed after the timeout")
+}
+
+func TestRunner_TimeoutKillsChildren(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	runner, bus := newTestRunner(t, domain.HooksConfig{Timeout: 1, SongStarted: `sleep 30 & echo $! > "` + pidFile + `"; wait`})
+
+	bus.Publish(ports.SongStartedEvent{Song: domain.Song{ID: "song1_id"}})
+	pid, err := strconv.Atoi(readOutput(t, pidFile))
+	require.NoError(t, err)
+	require.NoError(t, runner.Close())
+
+	require.Eventually(t, func() bool {
+		stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+		return err != nil || strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))[0] == "Z"
+	}, 5*time.Second, 10*time.Millisecond, "Children of a timed out hook should be killed too")
+}
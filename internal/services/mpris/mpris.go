@@ -0,0 +1,427 @@
+package mpris
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"time"
+	"yogo/internal/domain"
+	"yogo/internal/logger"
+	"yogo/internal/ports"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+const (
+	busName         = "org.mpris.MediaPlayer2.yogo"
+	objectPath      = dbus.ObjectPath("/org/mpris/MediaPlayer2")
+	trackPathBase   = "/org/mpris/MediaPlayer2/yogo/track/"
+	noTrack         = dbus.ObjectPath("/org/mpris/MediaPlayer2/TrackList/NoTrack")
+	rootInterface   = "org.mpris.MediaPlayer2"
+	playerIface     = "org.mpris.MediaPlayer2.Player"
+	seekedSignal    = playerIface + ".Seeked"
+	minimumRate     = 0.25
+	maximumRate     = 4.0
+	seekTolerance   = 1.5
+	microsPerSecond = 1e6
+)
+
+const (
+	statusPlaying = "Playing"
+	statusPaused  = "Paused"
+	statusStopped = "Stopped"
+)
+
+type Server struct {
+	conn    *dbus.Conn
+	player  ports.PlayerService
+	control ports.ControlHandler
+	props   *prop.Properties
+
+	mu        sync.Mutex
+	song      domain.Song
+	state     ports.PlayerState
+	updatedAt time.Time
+
+	done chan struct{}
+}
+
+func NewServer(bus ports.EventBus, player ports.PlayerService, control ports.ControlHandler) (*Server, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to the session bus: %w", err)
+	}
+	server, err := newServer(conn, bus, player, control)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return server, nil
+}
+
+func newServer(conn *dbus.Conn, bus ports.EventBus, player ports.PlayerService, control ports.ControlHandler) (*Server, error) {
+	s := &Server{
+		conn:      conn,
+		player:    player,
+		control:   control,
+		updatedAt: time.Now(),
+		done:      make(chan struct{}),
+	}
+	if state, err := player.GetState(); err == nil {
+		s.state = state
+	}
+
+	if err := s.export(); err != nil {
+		return nil, err
+	}
+	if err := s.requestName(); err != nil {
+		return nil, err
+	}
+
+	go s.watch(bus.Subscribe())
+	return s, nil
+}
+
+func (s *Server) export() error {
+	if err := s.conn.Export(rootMethods{}, objectPath, rootInterface); err != nil {
+		return fmt.Errorf("could not export %s: %w", rootInterface, err)
+	}
+	methods := playerMethods{s}
+	if err := s.conn.ExportWithMap(methods, playerMethodNames, objectPath, playerIface); err != nil {
+		return fmt.Errorf("could not export %s: %w", playerIface, err)
+	}
+
+	props, err := prop.Export(s.conn, objectPath, prop.Map{
+		rootInterface: {
+			"CanQuit":             {Value: false, Emit: prop.EmitConst},
+			"CanRaise":            {Value: false, Emit: prop.EmitConst},
+			"HasTrackList":        {Value: false, Emit: prop.EmitConst},
+			"Identity":            {Value: "yogo", Emit: prop.EmitConst},
+			"SupportedUriSchemes": {Value: []string{"https"}, Emit: prop.EmitConst},
+			"SupportedMimeTypes":  {Value: []string{}, Emit: prop.EmitConst},
+		},
+		playerIface: {
+			"PlaybackStatus": {Value: statusStopped, Emit: prop.EmitTrue},
+			"LoopStatus":     {Value: loopStatus(s.state.Loop), Emit: prop.EmitTrue},
+			"Rate":           {Value: rateOrDefault(s.state.Speed), Writable: true, Emit: prop.EmitTrue, Callback: s.setRate},
+			"Shuffle":        {Value: false, Emit: prop.EmitConst},
+			"Metadata":       {Value: s.metadata(), Emit: prop.EmitTrue},
+			"Volume":         {Value: s.state.Volume / 100, Writable: true, Emit: prop.EmitTrue, Callback: s.setVolume},
+			"Position":       {Value: int64(0), Emit: prop.EmitFalse},
+			"MinimumRate":    {Value: minimumRate, Emit: prop.EmitConst},
+			"MaximumRate":    {Value: maximumRate, Emit: prop.EmitConst},
+			"CanGoNext":      {Value: true, Emit: prop.EmitConst},
+			"CanGoPrevious":  {Value: false, Emit: prop.EmitConst},
+			"CanPlay":        {Value: false, Emit: prop.EmitTrue},
+			"CanPause":       {Value: false, Emit: prop.EmitTrue},
+			"CanSeek":        {Value: false, Emit: prop.EmitTrue},
+			"CanControl":     {Value: true, Emit: prop.EmitConst},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("could not export MPRIS properties: %w", err)
+	}
+	s.props = props
+
+	node := &introspect.Node{
+		Name: string(objectPath),
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			prop.IntrospectData,
+			{
+				Name:       rootInterface,
+				Methods:    introspect.Methods(rootMethods{}),
+				Properties: props.Introspection(rootInterface),
+			},
+			{
+				Name:       playerIface,
+				Methods:    playerIntrospection(methods),
+				Properties: props.Introspection(playerIface),
+				Signals: []introspect.Signal{{
+					Name: "Seeked",
+					Args: []introspect.Arg{{Name: "Position", Type: "x"}},
+				}},
+			},
+		},
+	}
+	return s.conn.Export(introspect.NewIntrospectable(node), objectPath, "org.freedesktop.DBus.Introspectable")
+}
+
+func (s *Server) requestName() error {
+	for _, name := range []string{busName, fmt.Sprintf("%s.instance%d", busName, os.Getpid())} {
+		reply, err := s.conn.RequestName(name, dbus.NameFlagDoNotQueue)
+		if err != nil {
+			return fmt.Errorf("could not request bus name %s: %w", name, err)
+		}
+		if reply == dbus.RequestNameReplyPrimaryOwner {
+			logger.Log.Info().Str("name", name).Msg("MPRIS interface registered")
+			return nil
+		}
+	}
+	return errors.New("could not own an MPRIS bus name")
+}
+
+func (s *Server) watch(events <-chan ports.Event) {
+	for {
+		select {
+		case <-s.done:
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			switch event := event.(type) {
+			case ports.PlayerStateChangedEvent:
+				s.updateState(event.State)
+			case ports.SongStartedEvent:
+				s.setSong(event.Song)
+			case ports.PlaybackStoppedEvent:
+				s.setSong(domain.Song{})
+			}
+		}
+	}
+}
+
+func (s *Server) setSong(song domain.Song) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.song = song
+	s.updatedAt = time.Now()
+	s.sync()
+}
+
+func (s *Server) updateState(state ports.PlayerState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.song.ID != "" && s.state.Duration == state.Duration {
+		expected := s.state.Position
+		if s.state.IsPlaying {
+			expected += now.Sub(s.updatedAt).Seconds() * rateOrDefault(s.state.Speed)
+		}
+		if math.Abs(state.Position-expected) > seekTolerance {
+			s.conn.Emit(objectPath, seekedSignal, toMicros(state.Position))
+		}
+	}
+
+	s.state = state
+	s.updatedAt = now
+	s.sync()
+}
+
+func (s *Server) sync() {
+	active := s.song.ID != ""
+	s.set(playerIface, "PlaybackStatus", s.playbackStatus())
+	s.set(playerIface, "LoopStatus", loopStatus(s.state.Loop))
+	s.set(playerIface, "Rate", rateOrDefault(s.state.Speed))
+	s.set(playerIface, "Volume", s.state.Volume/100)
+	s.set(playerIface, "CanPlay", active)
+	s.set(playerIface, "CanPause", active)
+	s.set(playerIface, "CanSeek", active)
+	s.props.SetMust(playerIface, "Position", toMicros(s.state.Position))
+
+	metadata := s.metadata()
+	current := s.props.GetMust(playerIface, "Metadata").(map[string]dbus.Variant)
+	if current["mpris:trackid"] != metadata["mpris:trackid"] || current["mpris:length"] != metadata["mpris:length"] {
+		s.props.SetMust(playerIface, "Metadata", metadata)
+	}
+}
+
+func (s *Server) set(iface, name string, value any) {
+	if s.props.GetMust(iface, name) != value {
+		s.props.SetMust(iface, name, value)
+	}
+}
+
+func (s *Server) playbackStatus() string {
+	switch {
+	case s.song.ID == "":
+		return statusStopped
+	case s.state.IsPlaying:
+		return statusPlaying
+	default:
+		return statusPaused
+	}
+}
+
+func (s *Server) metadata() map[string]dbus.Variant {
+	if s.song.ID == "" {
+		return map[string]dbus.Variant{"mpris:trackid": dbus.MakeVariant(noTrack)}
+	}
+	metadata := map[string]dbus.Variant{
+		"mpris:trackid": dbus.MakeVariant(trackPath(s.song.ID)),
+		"mpris:artUrl":  dbus.MakeVariant(artURL(s.song)),
+		"xesam:title":   dbus.MakeVariant(s.song.Title),
+		"xesam:url":     dbus.MakeVariant(fmt.Sprintf("https://www.youtube.com/watch?v=%s", s.song.ID)),
+	}
+	if len(s.song.Artists) > 0 {
+		metadata["xesam:artist"] = dbus.MakeVariant(s.song.Artists)
+	}
+	if s.state.Duration > 0 {
+		metadata["mpris:length"] = dbus.MakeVariant(toMicros(s.state.Duration))
+	}
+	return metadata
+}
+
+func (s *Server) setRate(c *prop.Change) *dbus.Error {
+	rate, _ := c.Value.(float64)
+	if rate < minimumRate || rate > maximumRate {
+		return prop.ErrInvalidArg
+	}
+	state, err := s.player.GetState()
+	if err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	if err := s.player.ChangeSpeed(rate - state.Speed); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+func (s *Server) setVolume(c *prop.Change) *dbus.Error {
+	volume, _ := c.Value.(float64)
+	if err := s.player.SetVolume(int(math.Round(max(0, volume) * 100))); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+func (s *Server) sendControl(command string, args ...string) *dbus.Error {
+	resp := s.control.Handle(ports.ControlRequest{Command: command, Args: args})
+	if !resp.OK {
+		return dbus.MakeFailedError(errors.New(resp.Error))
+	}
+	return nil
+}
+
+func (s *Server) snapshot() (domain.Song, ports.PlayerState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.song, s.state
+}
+
+func (s *Server) Close() error {
+	close(s.done)
+	return s.conn.Close()
+}
+
+type rootMethods struct{}
+
+func (rootMethods) Raise() *dbus.Error { return nil }
+func (rootMethods) Quit() *dbus.Error  { return nil }
+
+type playerMethods struct {
+	s *Server
+}
+
+var playerMethodNames = map[string]string{"SeekBy": "Seek"}
+
+func playerIntrospection(methods playerMethods) []introspect.Method {
+	introspection := introspect.Methods(methods)
+	for i, method := range introspection {
+		if name, ok := playerMethodNames[method.Name]; ok {
+			introspection[i].Name = name
+		}
+	}
+	return introspection
+}
+
+func (m playerMethods) Next() *dbus.Error {
+	return m.s.sendControl("next")
+}
+
+func (m playerMethods) Previous() *dbus.Error {
+	return nil
+}
+
+func (m playerMethods) Pause() *dbus.Error {
+	song, state := m.s.snapshot()
+	if song.ID == "" || !state.IsPlaying {
+		return nil
+	}
+	return failed(m.s.player.Pause())
+}
+
+func (m playerMethods) Play() *dbus.Error {
+	song, state := m.s.snapshot()
+	if song.ID == "" || state.IsPlaying {
+		return nil
+	}
+	return failed(m.s.player.Pause())
+}
+
+func (m playerMethods) PlayPause() *dbus.Error {
+	if song, _ := m.s.snapshot(); song.ID == "" {
+		return nil
+	}
+	return failed(m.s.player.Pause())
+}
+
+func (m playerMethods) Stop() *dbus.Error {
+	return m.s.sendControl("stop")
+}
+
+func (m playerMethods) SeekBy(offset int64) *dbus.Error {
+	if song, _ := m.s.snapshot(); song.ID == "" {
+		return nil
+	}
+	return failed(m.s.player.Seek(int(math.Round(float64(offset) / microsPerSecond))))
+}
+
+func (m playerMethods) SetPosition(track dbus.ObjectPath, position int64) *dbus.Error {
+	song, state := m.s.snapshot()
+	if song.ID == "" || track != trackPath(song.ID) {
+		return nil
+	}
+	target := float64(position) / microsPerSecond
+	if target < 0 || (state.Duration > 0 && target > state.Duration) {
+		return nil
+	}
+	return failed(m.s.player.SeekTo(target))
+}
+
+func (m playerMethods) OpenUri(uri string) *dbus.Error {
+	return m.s.sendControl("play", uri)
+}
+
+func failed(err error) *dbus.Error {
+	if err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+func trackPath(songID string) dbus.ObjectPath {
+	return dbus.ObjectPath(trackPathBase + hex.EncodeToString([]byte(songID)))
+}
+
+func artURL(song domain.Song) string {
+	if song.Thumbnail != "" {
+		return song.Thumbnail
+	}
+	return fmt.Sprintf("https://i.ytimg.com/vi/%s/hqdefault.jpg", song.ID)
+}
+
+func toMicros(seconds float64) int64 {
+	return int64(seconds * microsPerSecond)
+}
+
+func rateOrDefault(speed float64) float64 {
+	if speed <= 0 {
+		return 1
+	}
+	return speed
+}
+
+func loopStatus(mode domain.LoopMode) string {
+	if mode == domain.LoopOff || mode == "" {
+		return "None"
+	}
+	return "Track"
+}
@@ -0,0 +1,230 @@
+package mpris
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"yogo/internal/domain"
+	"yogo/internal/ports"
+	"yogo/internal/services/events"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/require"
+)
+
+const busConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:dir=%s</listen>
+  <auth>EXTERNAL</auth>
+  <policy context="default">
+    <allow send_destination="*" eavesdrop="true"/>
+    <allow eavesdrop="true"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>`
+
+type fakePlayer struct {
+	mu    sync.Mutex
+	state ports.PlayerState
+	calls []string
+	bus   *events.Bus
+}
+
+func (p *fakePlayer) record(call string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, call)
+	return nil
+}
+
+func (p *fakePlayer) Calls() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.calls...)
+}
+
+func (p *fakePlayer) Play(mediaURL string) error { return p.record("play " + mediaURL) }
+func (p *fakePlayer) Pause() error               { return p.record("pause") }
+func (p *fakePlayer) Stop() error                { return p.record("stop") }
+func (p *fakePlayer) Seek(seconds int) error     { return p.record(fmt.Sprintf("seek %d", seconds)) }
+func (p *fakePlayer) SeekTo(position float64) error {
+	return p.record(fmt.Sprintf("seek to %g", position))
+}
+func (p *fakePlayer) ChangeSpeed(delta float64) error {
+	return p.record(fmt.Sprintf("speed %+.2f", delta))
+}
+func (p *fakePlayer) ResetSpeed() error            { return p.record("reset speed") }
+func (p *fakePlayer) SetVolume(volume int) error   { return p.record(fmt.Sprintf("volume %d", volume)) }
+func (p *fakePlayer) ChangeVolume(delta int) error { return p.record(fmt.Sprintf("volume %+d", delta)) }
+func (p *fakePlayer) ToggleMute() error            { return p.record("mute") }
+func (p *fakePlayer) SetLoop(mode domain.LoopMode, repeatCount int) error {
+	return p.record("loop " + string(mode))
+}
+func (p *fakePlayer) GetState() (ports.PlayerState, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state, nil
+}
+func (p *fakePlayer) Subscribe() <-chan ports.PlayerEvent { return nil }
+func (p *fakePlayer) Close() error                        { return nil }
+
+func (p *fakePlayer) emit(state ports.PlayerState) {
+	p.mu.Lock()
+	p.state = state
+	p.mu.Unlock()
+	p.bus.Publish(ports.PlayerStateChangedEvent{State: state})
+}
+
+type stubControl struct {
+	mu       sync.Mutex
+	requests []ports.ControlRequest
+}
+
+func (c *stubControl) Handle(req ports.ControlRequest) ports.ControlResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, req)
+	return ports.ControlResponse{OK: true}
+}
+
+func (c *stubControl) Requests() []ports.ControlRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]ports.ControlRequest(nil), c.requests...)
+}
+
+func startBus(t *testing.T) string {
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon is not installed")
+	}
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "bus.conf")
+	require.NoError(t, os.WriteFile(configPath, []byte(fmt.Sprintf(busConfig, dir)), 0600))
+
+	cmd := exec.Command(daemon, "--config-file="+configPath, "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	require.NoError(t, err)
+	return strings.TrimSpace(address)
+}
+
+func connect(t *testing.T, address string) *dbus.Conn {
+	conn, err := dbus.Connect(address)
+	require.NoError(t, err)
+	return conn
+}
+
+func newTestServer(t *testing.T) (*events.Bus, *fakePlayer, *stubControl, dbus.BusObject, *dbus.Conn) {
+	address := startBus(t)
+	bus := events.NewBus()
+	t.Cleanup(bus.Close)
+	player := &fakePlayer{
+		state: ports.PlayerState{Speed: 1, Volume: 80},
+		bus:   bus,
+	}
+	control := &stubControl{}
+
+	server, err := newServer(connect(t, address), bus, player, control)
+	require.NoError(t, err)
+	t.Cleanup(func() { server.Close() })
+
+	client := connect(t, address)
+	t.Cleanup(func() { client.Close() })
+	return bus, player, control, client.Object(busName, objectPath), client
+}
+
+func getProperty(t *testing.T, obj dbus.BusObject, name string) any {
+	value, err := obj.GetProperty(playerIface + "." + name)
+	require.NoError(t, err)
+	return value.Value()
+}
+
+func TestServer_Properties(t *testing.T) {
+	bus, player, _, obj, client := newTestServer(t)
+
+	require.NoError(t, client.AddMatchSignal(
+		dbus.WithMatchObjectPath(objectPath),
+		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+	))
+	signals := make(chan *dbus.Signal, 16)
+	client.Signal(signals)
+
+	require.Equal(t, statusStopped, getProperty(t, obj, "PlaybackStatus"))
+	require.Equal(t, 0.8, getProperty(t, obj, "Volume"))
+
+	song := domain.Song{ID: "abc-_123", Title: "Song 1", Artists: []string{"Artist 1"}, Thumbnail: "https://i.ytimg.com/vi/abc-_123/maxresdefault.jpg"}
+	bus.Publish(ports.SongStartedEvent{Song: song})
+	player.emit(ports.PlayerState{IsPlaying: true, Position: 12, Duration: 200, Speed: 1, Volume: 80})
+
+	select {
+	case signal := <-signals:
+		require.Equal(t, "org.freedesktop.DBus.Properties.PropertiesChanged", signal.Name)
+		require.Equal(t, playerIface, signal.Body[0])
+	case <-time.After(time.Second):
+		t.Fatal("Expected a PropertiesChanged signal")
+	}
+
+	require.Eventually(t, func() bool {
+		return getProperty(t, obj, "PlaybackStatus") == statusPlaying
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, int64(12_000_000), getProperty(t, obj, "Position"))
+
+	metadata := getProperty(t, obj, "Metadata").(map[string]dbus.Variant)
+	require.Equal(t, "Song 1", metadata["xesam:title"].Value())
+	require.Equal(t, []string{"Artist 1"}, metadata["xesam:artist"].Value())
+	require.Equal(t, int64(200_000_000), metadata["mpris:length"].Value())
+	require.Equal(t, trackPath(song.ID), metadata["mpris:trackid"].Value())
+	require.Equal(t, song.Thumbnail, metadata["mpris:artUrl"].Value())
+
+	bus.Publish(ports.PlaybackStoppedEvent{})
+	require.Eventually(t, func() bool {
+		return getProperty(t, obj, "PlaybackStatus") == statusStopped
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestServer_Methods(t *testing.T) {
+	bus, player, control, obj, _ := newTestServer(t)
+
+	require.NoError(t, obj.Call(playerIface+".PlayPause", 0).Err)
+	require.Empty(t, player.Calls(), "Nothing should happen while no song is loaded")
+
+	song := domain.Song{ID: "song1_id", Title: "Song 1"}
+	bus.Publish(ports.SongStartedEvent{Song: song})
+	player.emit(ports.PlayerState{IsPlaying: true, Position: 20, Duration: 200, Speed: 1})
+	require.Eventually(t, func() bool {
+		return getProperty(t, obj, "PlaybackStatus") == statusPlaying
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, obj.Call(playerIface+".PlayPause", 0).Err)
+	require.NoError(t, obj.Call(playerIface+".Play", 0).Err)
+	require.NoError(t, obj.Call(playerIface+".Seek", 0, int64(10_000_000)).Err)
+	require.NoError(t, obj.Call(playerIface+".SetPosition", 0, trackPath(song.ID), int64(50_000_000)).Err)
+	require.NoError(t, obj.Call(playerIface+".SetPosition", 0, trackPath("other"), int64(50_000_000)).Err)
+	require.NoError(t, obj.SetProperty(playerIface+".Rate", dbus.MakeVariant(1.5)))
+	require.NoError(t, obj.SetProperty(playerIface+".Volume", dbus.MakeVariant(0.5)))
+	require.Equal(t, []string{"pause", "seek 10", "seek to 50", "speed +0.50", "volume 50"}, player.Calls())
+
+	require.NoError(t, obj.Call(playerIface+".Next", 0).Err)
+	require.NoError(t, obj.Call(playerIface+".OpenUri", 0, "https://www.youtube.com/watch?v=xyz").Err)
+	require.Equal(t, []ports.ControlRequest{
+		{Command: "next"},
+		{Command: "play", Args: []string{"https://www.youtube.com/watch?v=xyz"}},
+	}, control.Requests())
+}
@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"yogo/internal/domain"
+	"yogo/internal/logger"
+	"yogo/internal/ports"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	notificationsName   = "org.freedesktop.Notifications"
+	notificationsPath   = dbus.ObjectPath("/org/freedesktop/Notifications")
+	notifyMethod        = notificationsName + ".Notify"
+	appName             = "yogo"
+	defaultIcon         = "audio-x-generic"
+	thumbnailURL        = "https://i.ytimg.com/vi/%s/hqdefault.jpg"
+	thumbnailTimeout    = 5 * time.Second
+	maxThumbnailSize    = 2 << 20
+	serverDefaultExpiry = -1
+)
+
+var markupEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+type Notifier struct {
+	conn         *dbus.Conn
+	cfg          domain.NotificationsConfig
+	thumbnailURL string
+	cacheDir     string
+	httpClient   *http.Client
+
+	lastID uint32
+	done   chan struct{}
+}
+
+func NewNotifier(cfg domain.NotificationsConfig, bus ports.EventBus) (*Notifier, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to the session bus: %w", err)
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+	return newNotifier(conn, cfg, bus, thumbnailURL, filepath.Join(cacheDir, "yogo", "thumbnails")), nil
+}
+
+func newNotifier(conn *dbus.Conn, cfg domain.NotificationsConfig, bus ports.EventBus, thumbnailURL, cacheDir string) *Notifier {
+	n := &Notifier{
+		conn:         conn,
+		cfg:          cfg,
+		thumbnailURL: thumbnailURL,
+		cacheDir:     cacheDir,
+		httpClient:   &http.Client{Timeout: thumbnailTimeout},
+		done:         make(chan struct{}),
+	}
+	go n.watch(bus.Subscribe())
+	return n
+}
+
+func (n *Notifier) watch(events <-chan ports.Event) {
+	for {
+		select {
+		case <-n.done:
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if started, ok := event.(ports.SongStartedEvent); ok {
+				if err := n.notify(started.Song); err != nil {
+					logger.Log.Warn().Err(err).Str("songID", started.Song.ID).Msg("Could not send the desktop notification")
+				}
+			}
+		}
+	}
+}
+
+func (n *Notifier) notify(song domain.Song) error {
+	hints := map[string]dbus.Variant{
+		"urgency":  dbus.MakeVariant(byte(0)),
+		"category": dbus.MakeVariant("x-yogo.nowplaying"),
+	}
+	icon := defaultIcon
+	if n.cfg.Thumbnail {
+		if path, err := n.thumbnail(song.ID); err != nil {
+			logger.Log.Warn().Err(err).Str("songID", song.ID).Msg("Could not download the thumbnail")
+		} else {
+			hints["image-path"] = dbus.MakeVariant("file://" + path)
+			icon = ""
+		}
+	}
+
+	expiry := int32(serverDefaultExpiry)
+	if n.cfg.Timeout > 0 {
+		expiry = int32(n.cfg.Timeout * 1000)
+	}
+
+	var id uint32
+	err := n.conn.Object(notificationsName, notificationsPath).Call(notifyMethod, 0,
+		appName,
+		n.lastID,
+		icon,
+		song.Title,
+		markupEscaper.Replace(strings.Join(song.Artists, ", ")),
+		[]string{},
+		hints,
+		expiry,
+	).Store(&id)
+	if err != nil {
+		return err
+	}
+	n.lastID = id
+	return nil
+}
+
+func (n *Notifier) thumbnail(songID string) (string, error) {
+	path := filepath.Join(n.cacheDir, songID+".jpg")
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	resp, err := n.httpClient.Get(fmt.Sprintf(n.thumbnailURL, songID))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	if err := os.MkdirAll(n.cacheDir, 0755); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(n.cacheDir, songID+"-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, io.LimitReader(resp.Body, maxThumbnailSize)); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	return path, os.Rename(tmp.Name(), path)
+}
+
+func (n *Notifier) Close() error {
+	close(n.done)
+	return n.conn.Close()
+}
@@ -0,0 +1,174 @@
+package notify
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"yogo/internal/domain"
+	"yogo/internal/ports"
+	"yogo/internal/services/events"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/require"
+)
+
+const busConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:dir=%s</listen>
+  <auth>EXTERNAL</auth>
+  <policy context="default">
+    <allow send_destination="*" eavesdrop="true"/>
+    <allow eavesdrop="true"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>`
+
+type notification struct {
+	ReplacesID uint32
+	Icon       string
+	Summary    string
+	Body       string
+	Hints      map[string]dbus.Variant
+	Timeout    int32
+}
+
+type stubServer struct {
+	mu            sync.Mutex
+	notifications []notification
+}
+
+func (s *stubServer) Notify(appName string, replacesID uint32, icon, summary, body string, actions []string, hints map[string]dbus.Variant, timeout int32) (uint32, *dbus.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notifications = append(s.notifications, notification{replacesID, icon, summary, body, hints, timeout})
+	if replacesID != 0 {
+		return replacesID, nil
+	}
+	return uint32(len(s.notifications)), nil
+}
+
+func (s *stubServer) received() []notification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]notification(nil), s.notifications...)
+}
+
+func startBus(t *testing.T) string {
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon is not installed")
+	}
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "bus.conf")
+	require.NoError(t, os.WriteFile(configPath, []byte(fmt.Sprintf(busConfig, dir)), 0600))
+
+	cmd := exec.Command(daemon, "--config-file="+configPath, "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	require.NoError(t, err)
+	return strings.TrimSpace(address)
+}
+
+func connect(t *testing.T, address string) *dbus.Conn {
+	conn, err := dbus.Connect(address)
+	require.NoError(t, err)
+	return conn
+}
+
+func startStubServer(t *testing.T, address string) *stubServer {
+	conn := connect(t, address)
+	t.Cleanup(func() { conn.Close() })
+	server := &stubServer{}
+	require.NoError(t, conn.Export(server, notificationsPath, notificationsName))
+	reply, err := conn.RequestName(notificationsName, dbus.NameFlagDoNotQueue)
+	require.NoError(t, err)
+	require.Equal(t, dbus.RequestNameReplyPrimaryOwner, reply)
+	return server
+}
+
+func TestNotifier(t *testing.T) {
+	address := startBus(t)
+	server := startStubServer(t, address)
+
+	var downloads atomic.Int32
+	thumbnails := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/vi/song1_id/hqdefault.jpg" {
+			http.NotFound(w, r)
+			return
+		}
+		downloads.Add(1)
+		w.Write([]byte("jpeg"))
+	}))
+	defer thumbnails.Close()
+
+	bus := events.NewBus()
+	defer bus.Close()
+	cacheDir := t.TempDir()
+	cfg := domain.NotificationsConfig{Enabled: true, Timeout: 3, Thumbnail: true}
+	notifier := newNotifier(connect(t, address), cfg, bus, thumbnails.URL+"/vi/%s/hqdefault.jpg", cacheDir)
+	defer notifier.Close()
+
+	bus.Publish(ports.SongStartedEvent{Song: domain.Song{ID: "song1_id", Title: "Song 1", Artists: []string{"Artist & Co", "Artist 2"}}})
+	bus.Publish(ports.PlaybackStoppedEvent{})
+	bus.Publish(ports.SongStartedEvent{Song: domain.Song{ID: "song2_id", Title: "Song 2"}})
+	bus.Publish(ports.SongStartedEvent{Song: domain.Song{ID: "song1_id", Title: "Song 1"}})
+
+	require.Eventually(t, func() bool { return len(server.received()) == 3 }, 2*time.Second, 10*time.Millisecond)
+	received := server.received()
+
+	first := received[0]
+	require.Equal(t, uint32(0), first.ReplacesID)
+	require.Equal(t, "Song 1", first.Summary)
+	require.Equal(t, "Artist &amp; Co, Artist 2", first.Body)
+	require.Equal(t, int32(3000), first.Timeout)
+	thumbnailPath := filepath.Join(cacheDir, "song1_id.jpg")
+	require.Equal(t, "file://"+thumbnailPath, first.Hints["image-path"].Value())
+	data, err := os.ReadFile(thumbnailPath)
+	require.NoError(t, err)
+	require.Equal(t, "jpeg", string(data))
+
+	second := received[1]
+	require.Equal(t, uint32(1), second.ReplacesID, "Notifications should replace the previous one")
+	require.Equal(t, defaultIcon, second.Icon, "The generic icon should be used without a thumbnail")
+	require.NotContains(t, second.Hints, "image-path")
+
+	require.Equal(t, uint32(1), received[2].ReplacesID)
+	require.Equal(t, int32(1), downloads.Load(), "Thumbnails should be cached")
+}
+
+func TestNotifier_WithoutThumbnail(t *testing.T) {
+	address := startBus(t)
+	server := startStubServer(t, address)
+
+	bus := events.NewBus()
+	defer bus.Close()
+	notifier := newNotifier(connect(t, address), domain.NotificationsConfig{Enabled: true}, bus, "http://127.0.0.1:0/%s", t.TempDir())
+	defer notifier.Close()
+
+	bus.Publish(ports.SongStartedEvent{Song: domain.Song{ID: "song1_id", Title: "Song 1"}})
+
+	require.Eventually(t, func() bool { return len(server.received()) == 1 }, 2*time.Second, 10*time.Millisecond)
+	received := server.received()[0]
+	require.Equal(t, int32(serverDefaultExpiry), received.Timeout)
+	require.Equal(t, defaultIcon, received.Icon)
+	require.NotContains(t, received.Hints, "image-path")
+}
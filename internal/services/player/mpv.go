@@ -3,7 +3,9 @@ package player
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net"
 	"os"
 	"os/exec"
@@ -15,13 +17,31 @@ import (
 )
 
 const (
-	socketCheckRetries   = 20
-	socketCheckInterval  = 100 * time.Millisecond
-	socketReadDeadline   = 500 * time.Millisecond
-	mpvCommandReqIDPause = 1
-	mpvCommandReqIDPos   = 2
-	mpvCommandReqIDDur   = 3
-	mpvCommandReqIDSpeed = 4
+	socketCheckRetries     = 20
+	socketCheckInterval    = 100 * time.Millisecond
+	commandReplyTimeout    = 500 * time.Millisecond
+	positionUpdateStep     = 0.25
+	mpvObserveIDPause      = 1
+	mpvObserveIDPos        = 2
+	mpvObserveIDDur        = 3
+	mpvObserveIDSpeed      = 4
+	mpvObserveIDVolume     = 5
+	mpvObserveIDMute       = 6
+	mpvObserveIDLoop       = 7
+	mpvEventPropertyChange = "property-change"
+	mpvEventEndFile        = "end-file"
+	mpvEventFileLoaded     = "file-loaded"
+	mpvEndFileReasonEOF    = "eof"
+	mpvEndFileReasonError  = "error"
+	mpvReplySuccess        = "success"
+	subscriberBufferSize   = 16
+	maxRestarts            = 3
+	restartWindow          = time.Minute
+)
+
+var (
+	execCommand     = exec.Command
+	errNotConnected = errors.New("not connected to mpv")
 )
 
 type MpvCommand struct {
@@ -34,6 +54,10 @@ type MpvResponse struct {
 	Data      any    `json:"data"`
 	RequestID int    `json:"request_id"`
 	Event     string `json:"event"`
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Reason    string `json:"reason"`
+	FileError string `json:"file_error"`
 }
 
 type MpvPlayer struct {
@@ -41,6 +65,20 @@ type MpvPlayer struct {
 	cmd        *exec.Cmd
 	mu         sync.Mutex
 	config     domain.PlaybackConfig
+	closing    bool
+	restarts   []time.Time
+
+	connMu    sync.Mutex
+	conn      net.Conn
+	nextReqID int
+	pending   map[int]chan MpvResponse
+
+	stateMu       sync.Mutex
+	state         ports.PlayerState
+	lastPublished float64
+	subscribers   []chan ports.PlayerEvent
+	currentURL    string
+	resumeAt      float64
 }
 
 func NewMpvPlayer(socketPath string, cfg domain.Config) ports.PlayerService {
@@ -48,7 +86,38 @@ func NewMpvPlayer(socketPath string, cfg domain.Config) ports.PlayerService {
 	return &MpvPlayer{
 		socketPath: socketPath,
 		config:     cfg.Playback,
+		pending:    make(map[int]chan MpvResponse),
+		state: ports.PlayerState{
+			Volume:      float64(cfg.Playback.Volume),
+			Loop:        cfg.Playback.LoopMode,
+			RepeatCount: cfg.Playback.RepeatCount,
+		},
+	}
+}
+
+func loopFileValue(mode domain.LoopMode, repeatCount int) any {
+	switch mode {
+	case domain.LoopInfinite:
+		return "inf"
+	case domain.LoopRepeat:
+		return repeatCount
+	default:
+		return "no"
+	}
+}
+
+func parseLoopFile(data any) (domain.LoopMode, int) {
+	switch value := data.(type) {
+	case string:
+		if value == "inf" {
+			return domain.LoopInfinite, 0
+		}
+	case float64:
+		if value > 0 {
+			return domain.LoopRepeat, int(value)
+		}
 	}
+	return domain.LoopOff, 0
 }
 
 func (p *MpvPlayer) isProcessRunning() bool {
@@ -58,13 +127,15 @@ func (p *MpvPlayer) isProcessRunning() bool {
 func (p *MpvPlayer) startMpvProcess() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	return p.startMpvProcessUnsafe()
+}
 
+func (p *MpvPlayer) startMpvProcessUnsafe() error {
 	if p.isProcessRunning() {
-		if p.cmd.ProcessState != nil && p.cmd.ProcessState.Exited() {
-			p.cmd = nil
-		} else {
-			return nil
+		if !p.isConnected() {
+			return p.connect()
 		}
+		return nil
 	}
 
 	logger.Log.Info().Msg("Starting new mpv process...")
@@ -73,19 +144,18 @@ func (p *MpvPlayer) startMpvProcess() error {
 		"--input-ipc-server=" + p.socketPath,
 		"--no-video",
 		"--no-config",
+		fmt.Sprintf("--volume=%d", p.config.Volume),
+		fmt.Sprintf("--volume-max=%d", domain.MaxVolume),
 	}
 
-	if p.config.Loop {
-		args = append(args, "--loop-file=yes")
-	} else {
-		args = append(args, "--loop-file=no")
-	}
+	args = append(args, fmt.Sprintf("--loop-file=%v", loopFileValue(p.config.LoopMode, p.config.RepeatCount)))
 
 	if p.config.SavePositionOnQuit {
 		args = append(args, "--save-position-on-quit")
 	}
 
-	p.cmd = exec.Command("mpv", args...)
+	os.Remove(p.socketPath)
+	p.cmd = execCommand("mpv", args...)
 	p.cmd.Stdout = logger.Log
 	p.cmd.Stderr = logger.Log
 
@@ -93,11 +163,12 @@ func (p *MpvPlayer) startMpvProcess() error {
 		p.cmd = nil
 		return fmt.Errorf("could not start mpv process: %w", err)
 	}
+	go p.supervise(p.cmd)
 
 	for range socketCheckRetries {
 		if _, err := os.Stat(p.socketPath); err == nil {
 			logger.Log.Info().Msg("mpv socket detected. Process ready.")
-			return nil
+			return p.connect()
 		}
 		time.Sleep(socketCheckInterval)
 	}
@@ -108,32 +179,133 @@ func (p *MpvPlayer) startMpvProcess() error {
 	return fmt.Errorf("mpv process started but socket did not appear at %s", p.socketPath)
 }
 
-func (p *MpvPlayer) sendCommands(cmds ...MpvCommand) ([]MpvResponse, error) {
+func (p *MpvPlayer) supervise(cmd *exec.Cmd) {
+	waitErr := cmd.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cmd != cmd {
+		return
+	}
+	p.cmd = nil
+	p.disconnect()
+	if p.closing {
+		return
+	}
+
+	logger.Log.Error().Err(waitErr).Msg("mpv exited unexpectedly")
+
+	p.stateMu.Lock()
+	mediaURL := p.currentURL
+	position := p.state.Position
+	p.stateMu.Unlock()
+	if mediaURL == "" {
+		p.publishError(fmt.Errorf("mpv exited unexpectedly: %v", waitErr))
+		return
+	}
+	p.publishError(fmt.Errorf("mpv exited unexpectedly (%v), restarting", waitErr))
+
+	if !p.allowRestart() {
+		p.clearCurrent()
+		p.publishError(fmt.Errorf("mpv exited %d times in the last %s, giving up", maxRestarts, restartWindow))
+		return
+	}
+
+	if err := p.startMpvProcessUnsafe(); err != nil {
+		p.clearCurrent()
+		p.publishError(err)
+		return
+	}
+
+	p.stateMu.Lock()
+	p.resumeAt = position
+	p.stateMu.Unlock()
+	if _, err := p.sendCommands(MpvCommand{Command: []any{"loadfile", mediaURL, "replace"}}); err != nil {
+		p.clearCurrent()
+		p.publishError(err)
+		return
+	}
+
+	logger.Log.Info().Float64("position", position).Msg("mpv restarted, current song reloaded")
+	p.publish(ports.PlayerEvent{Type: ports.PlayerRecovered, State: p.snapshot()})
+}
+
+func (p *MpvPlayer) allowRestart() bool {
+	now := time.Now()
+	recent := p.restarts[:0]
+	for _, t := range p.restarts {
+		if now.Sub(t) < restartWindow {
+			recent = append(recent, t)
+		}
+	}
+	p.restarts = recent
+	if len(p.restarts) >= maxRestarts {
+		return false
+	}
+	p.restarts = append(p.restarts, now)
+	return true
+}
+
+func (p *MpvPlayer) clearCurrent() {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+	p.currentURL = ""
+	p.resumeAt = 0
+}
+
+func (p *MpvPlayer) snapshot() ports.PlayerState {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+	return p.state
+}
+
+func (p *MpvPlayer) isConnected() bool {
+	p.connMu.Lock()
+	defer p.connMu.Unlock()
+	return p.conn != nil
+}
+
+func (p *MpvPlayer) connect() error {
 	conn, err := net.Dial("unix", p.socketPath)
 	if err != nil {
-		return nil, fmt.Errorf("could not connect to mpv socket: %w", err)
+		return fmt.Errorf("could not connect to mpv socket: %w", err)
 	}
-	defer conn.Close()
 
-	conn.SetReadDeadline(time.Now().Add(socketReadDeadline))
+	p.connMu.Lock()
+	p.conn = conn
+	p.connMu.Unlock()
+
+	go p.readLoop(conn)
+
+	_, err = p.sendCommands(
+		MpvCommand{Command: []any{"observe_property", mpvObserveIDPause, "pause"}},
+		MpvCommand{Command: []any{"observe_property", mpvObserveIDPos, "time-pos"}},
+		MpvCommand{Command: []any{"observe_property", mpvObserveIDDur, "duration"}},
+		MpvCommand{Command: []any{"observe_property", mpvObserveIDSpeed, "speed"}},
+		MpvCommand{Command: []any{"observe_property", mpvObserveIDVolume, "volume"}},
+		MpvCommand{Command: []any{"observe_property", mpvObserveIDMute, "mute"}},
+		MpvCommand{Command: []any{"observe_property", mpvObserveIDLoop, "loop-file"}},
+	)
+	return err
+}
 
-	encoder := json.NewEncoder(conn)
-	for _, cmd := range cmds {
-		if err := encoder.Encode(cmd); err != nil {
-			return nil, fmt.Errorf("error sending mpv command: %w", err)
-		}
+func (p *MpvPlayer) disconnect() {
+	p.connMu.Lock()
+	defer p.connMu.Unlock()
+	if p.conn == nil {
+		return
 	}
+	p.conn.Close()
+	p.conn = nil
+	for id, ch := range p.pending {
+		close(ch)
+		delete(p.pending, id)
+	}
+}
 
-	var responses []MpvResponse
+func (p *MpvPlayer) readLoop(conn net.Conn) {
 	scanner := bufio.NewScanner(conn)
-	for len(responses) < len(cmds) {
-		if !scanner.Scan() {
-			if err := scanner.Err(); err != nil {
-				logger.Log.Error().Err(err).Msg("Error reading from mpv socket")
-			}
-			break
-		}
-
+	for scanner.Scan() {
 		line := scanner.Bytes()
 		var resp MpvResponse
 		if err := json.Unmarshal(line, &resp); err != nil {
@@ -141,8 +313,174 @@ func (p *MpvPlayer) sendCommands(cmds ...MpvCommand) ([]MpvResponse, error) {
 			continue
 		}
 
-		if resp.Event == "" && resp.RequestID > 0 {
+		if resp.Event != "" {
+			p.handleEvent(resp)
+			continue
+		}
+
+		p.connMu.Lock()
+		ch, ok := p.pending[resp.RequestID]
+		delete(p.pending, resp.RequestID)
+		p.connMu.Unlock()
+		if ok {
+			ch <- resp
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		logger.Log.Error().Err(err).Msg("Error reading from mpv socket")
+	}
+
+	p.connMu.Lock()
+	stale := p.conn != conn
+	p.connMu.Unlock()
+	if !stale {
+		logger.Log.Warn().Msg("mpv IPC connection closed")
+		p.disconnect()
+	}
+}
+
+func (p *MpvPlayer) handleEvent(resp MpvResponse) {
+	switch resp.Event {
+	case mpvEventPropertyChange:
+		p.handlePropertyChange(resp)
+	case mpvEventEndFile:
+		var err error
+		switch resp.Reason {
+		case mpvEndFileReasonEOF:
+		case mpvEndFileReasonError:
+			err = fmt.Errorf("mpv could not play the track: %s", resp.FileError)
+		default:
+			return
+		}
+		p.clearCurrent()
+		p.publish(ports.PlayerEvent{Type: ports.PlayerTrackEnded, State: p.snapshot(), Err: err})
+	case mpvEventFileLoaded:
+		p.stateMu.Lock()
+		position := p.resumeAt
+		p.resumeAt = 0
+		p.stateMu.Unlock()
+		if position > 0 {
+			go func() {
+				cmd := MpvCommand{Command: []any{"seek", position, "absolute"}}
+				if _, err := p.sendCommands(cmd); err != nil {
+					logger.Log.Error().Err(err).Msg("Could not restore position after restart")
+				}
+			}()
+		}
+	}
+}
+
+func (p *MpvPlayer) handlePropertyChange(resp MpvResponse) {
+	p.stateMu.Lock()
+	switch resp.ID {
+	case mpvObserveIDPause:
+		if isPaused, ok := resp.Data.(bool); ok {
+			p.state.IsPlaying = !isPaused
+		}
+	case mpvObserveIDPos:
+		pos, _ := resp.Data.(float64)
+		p.state.Position = pos
+		if math.Abs(pos-p.lastPublished) < positionUpdateStep {
+			p.stateMu.Unlock()
+			return
+		}
+	case mpvObserveIDDur:
+		dur, _ := resp.Data.(float64)
+		p.state.Duration = dur
+	case mpvObserveIDSpeed:
+		if speed, ok := resp.Data.(float64); ok {
+			p.state.Speed = speed
+		}
+	case mpvObserveIDVolume:
+		if volume, ok := resp.Data.(float64); ok {
+			p.state.Volume = volume
+		}
+	case mpvObserveIDMute:
+		if muted, ok := resp.Data.(bool); ok {
+			p.state.Muted = muted
+		}
+	case mpvObserveIDLoop:
+		p.state.Loop, p.state.RepeatCount = parseLoopFile(resp.Data)
+	}
+	p.lastPublished = p.state.Position
+	state := p.state
+	p.stateMu.Unlock()
+
+	p.publish(ports.PlayerEvent{Type: ports.PlayerStateChanged, State: state})
+}
+
+func (p *MpvPlayer) publishError(err error) {
+	p.publish(ports.PlayerEvent{Type: ports.PlayerProcessExited, State: p.snapshot(), Err: err})
+}
+
+func (p *MpvPlayer) publish(event ports.PlayerEvent) {
+	p.stateMu.Lock()
+	subscribers := p.subscribers
+	p.stateMu.Unlock()
+
+	for _, ch := range subscribers {
+		select {
+		case ch <- event:
+		default:
+			logger.Log.Warn().Int("type", int(event.Type)).Msg("Player subscriber is not keeping up, dropping oldest event")
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- event:
+			default:
+			}
+		}
+	}
+}
+
+func (p *MpvPlayer) sendCommands(cmds ...MpvCommand) ([]MpvResponse, error) {
+	p.connMu.Lock()
+	conn := p.conn
+	if conn == nil {
+		p.connMu.Unlock()
+		return nil, errNotConnected
+	}
+
+	reqIDs := make([]int, len(cmds))
+	replies := make([]chan MpvResponse, len(cmds))
+	encoder := json.NewEncoder(conn)
+	for i, cmd := range cmds {
+		p.nextReqID++
+		cmd.RequestID = p.nextReqID
+		reqIDs[i] = cmd.RequestID
+		replies[i] = make(chan MpvResponse, 1)
+		p.pending[cmd.RequestID] = replies[i]
+		if err := encoder.Encode(cmd); err != nil {
+			p.connMu.Unlock()
+			return nil, fmt.Errorf("error sending mpv command: %w", err)
+		}
+	}
+	p.connMu.Unlock()
+
+	var responses []MpvResponse
+	timeout := time.After(commandReplyTimeout)
+	for _, reply := range replies {
+		select {
+		case resp, ok := <-reply:
+			if !ok {
+				return responses, errNotConnected
+			}
 			responses = append(responses, resp)
+		case <-timeout:
+			logger.Log.Warn().Int("expected", len(cmds)).Int("received", len(responses)).Msg("Timed out waiting for mpv replies")
+			p.connMu.Lock()
+			for _, id := range reqIDs {
+				delete(p.pending, id)
+			}
+			p.connMu.Unlock()
+			return responses, fmt.Errorf("timed out waiting for mpv to reply to %v", cmds[len(responses)].Command)
+		}
+	}
+	for i, resp := range responses {
+		if resp.Error != mpvReplySuccess {
+			return responses, fmt.Errorf("mpv rejected %v: %s", cmds[i].Command, resp.Error)
 		}
 	}
 	return responses, nil
@@ -153,14 +491,20 @@ func (p *MpvPlayer) Play(mediaURL string) error {
 		return err
 	}
 	loadFileCmd := MpvCommand{Command: []any{"loadfile", mediaURL, "replace"}}
-	_, err := p.sendCommands(loadFileCmd)
-	return err
+	if _, err := p.sendCommands(loadFileCmd); err != nil {
+		return err
+	}
+	p.stateMu.Lock()
+	p.currentURL = mediaURL
+	p.resumeAt = 0
+	p.stateMu.Unlock()
+	return nil
 }
 
 func (p *MpvPlayer) Pause() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	if !p.isProcessRunning() {
+	if !p.isConnected() {
 		return nil
 	}
 	cmd := MpvCommand{Command: []any{"cycle", "pause"}}
@@ -171,9 +515,10 @@ func (p *MpvPlayer) Pause() error {
 func (p *MpvPlayer) Stop() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	if !p.isProcessRunning() {
+	if !p.isConnected() {
 		return nil
 	}
+	p.clearCurrent()
 	cmd := MpvCommand{Command: []any{"stop"}}
 	_, err := p.sendCommands(cmd)
 	return err
@@ -182,7 +527,7 @@ func (p *MpvPlayer) Stop() error {
 func (p *MpvPlayer) Seek(seconds int) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	if !p.isProcessRunning() {
+	if !p.isConnected() {
 		return nil
 	}
 	cmd := MpvCommand{Command: []any{"seek", seconds, "relative"}}
@@ -190,10 +535,21 @@ func (p *MpvPlayer) Seek(seconds int) error {
 	return err
 }
 
+func (p *MpvPlayer) SeekTo(position float64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.isConnected() {
+		return nil
+	}
+	cmd := MpvCommand{Command: []any{"seek", position, "absolute"}}
+	_, err := p.sendCommands(cmd)
+	return err
+}
+
 func (p *MpvPlayer) ChangeSpeed(delta float64) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	if !p.isProcessRunning() {
+	if !p.isConnected() {
 		return nil
 	}
 	cmd := MpvCommand{Command: []any{"add", "speed", delta}}
@@ -204,7 +560,7 @@ func (p *MpvPlayer) ChangeSpeed(delta float64) error {
 func (p *MpvPlayer) ResetSpeed() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	if !p.isProcessRunning() {
+	if !p.isConnected() {
 		return nil
 	}
 	cmd := MpvCommand{Command: []any{"set_property", "speed", 1.0}}
@@ -212,57 +568,78 @@ func (p *MpvPlayer) ResetSpeed() error {
 	return err
 }
 
-func (p *MpvPlayer) getStateUnsafe() (ports.PlayerState, error) {
-	state := ports.PlayerState{}
-	if !p.isProcessRunning() {
-		return state, nil
+func (p *MpvPlayer) SetVolume(volume int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	volume = max(0, min(volume, domain.MaxVolume))
+	p.config.Volume = volume
+	if !p.isConnected() {
+		p.stateMu.Lock()
+		p.state.Volume = float64(volume)
+		state := p.state
+		p.stateMu.Unlock()
+		p.publish(ports.PlayerEvent{Type: ports.PlayerStateChanged, State: state})
+		return nil
 	}
+	cmd := MpvCommand{Command: []any{"set_property", "volume", volume}}
+	_, err := p.sendCommands(cmd)
+	return err
+}
 
-	pauseCmd := MpvCommand{Command: []any{"get_property", "pause"}, RequestID: mpvCommandReqIDPause}
-	posCmd := MpvCommand{Command: []any{"get_property", "time-pos"}, RequestID: mpvCommandReqIDPos}
-	durCmd := MpvCommand{Command: []any{"get_property", "duration"}, RequestID: mpvCommandReqIDDur}
-	speedCmd := MpvCommand{Command: []any{"get_property", "speed"}, RequestID: mpvCommandReqIDSpeed}
+func (p *MpvPlayer) ChangeVolume(delta int) error {
+	state, _ := p.GetState()
+	return p.SetVolume(int(math.Round(state.Volume)) + delta)
+}
 
-	responses, err := p.sendCommands(pauseCmd, posCmd, durCmd, speedCmd)
-	if err != nil {
-		return state, err
+func (p *MpvPlayer) ToggleMute() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.isConnected() {
+		return nil
 	}
+	cmd := MpvCommand{Command: []any{"cycle", "mute"}}
+	_, err := p.sendCommands(cmd)
+	return err
+}
 
-	for _, resp := range responses {
-		if resp.Error != "success" {
-			continue
-		}
-		switch resp.RequestID {
-		case mpvCommandReqIDPause:
-			if isPaused, ok := resp.Data.(bool); ok {
-				state.IsPlaying = !isPaused
-			}
-		case mpvCommandReqIDPos:
-			if pos, ok := resp.Data.(float64); ok {
-				state.Position = pos
-			}
-		case mpvCommandReqIDDur:
-			if dur, ok := resp.Data.(float64); ok {
-				state.Duration = dur
-			}
-		case mpvCommandReqIDSpeed:
-			if speed, ok := resp.Data.(float64); ok {
-				state.Speed = speed
-			}
-		}
+func (p *MpvPlayer) SetLoop(mode domain.LoopMode, repeatCount int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.config.LoopMode = mode
+	p.config.RepeatCount = repeatCount
+	if !p.isConnected() {
+		p.stateMu.Lock()
+		p.state.Loop = mode
+		p.state.RepeatCount = repeatCount
+		state := p.state
+		p.stateMu.Unlock()
+		p.publish(ports.PlayerEvent{Type: ports.PlayerStateChanged, State: state})
+		return nil
 	}
-	return state, nil
+	cmd := MpvCommand{Command: []any{"set_property", "loop-file", loopFileValue(mode, repeatCount)}}
+	_, err := p.sendCommands(cmd)
+	return err
 }
 
 func (p *MpvPlayer) GetState() (ports.PlayerState, error) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	return p.getStateUnsafe()
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+	return p.state, nil
+}
+
+func (p *MpvPlayer) Subscribe() <-chan ports.PlayerEvent {
+	ch := make(chan ports.PlayerEvent, subscriberBufferSize)
+	p.stateMu.Lock()
+	p.subscribers = append(p.subscribers, ch)
+	p.stateMu.Unlock()
+	return ch
 }
 
 func (p *MpvPlayer) Close() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	p.closing = true
+	p.disconnect()
 	if p.isProcessRunning() {
 		if err := p.cmd.Process.Kill(); err != nil {
 			logger.Log.Error().Err(err).Msg("Error terminating mpv process")
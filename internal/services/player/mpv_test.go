@@ -0,0 +1,294 @@
+package player
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"yogo/internal/domain"
+	"yogo/internal/ports"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeMpv struct {
+	t        *testing.T
+	listener net.Listener
+	mu       sync.Mutex
+	conn     net.Conn
+	commands [][]any
+	reply    string
+}
+
+func newFakeMpv(t *testing.T, socketPath string) *fakeMpv {
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	f := &fakeMpv{t: t, listener: listener, reply: "success"}
+	t.Cleanup(func() { listener.Close() })
+	go f.serve()
+	return f
+}
+
+func (f *fakeMpv) serve() {
+	conn, err := f.listener.Accept()
+	if err != nil {
+		return
+	}
+	f.mu.Lock()
+	f.conn = conn
+	f.mu.Unlock()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var cmd MpvCommand
+		if err := json.Unmarshal(scanner.Bytes(), &cmd); err != nil {
+			continue
+		}
+		f.mu.Lock()
+		f.commands = append(f.commands, cmd.Command)
+		reply := f.reply
+		f.mu.Unlock()
+		f.send(map[string]any{"error": reply, "request_id": cmd.RequestID})
+	}
+}
+
+func (f *fakeMpv) send(msg map[string]any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	require.NoError(f.t, json.NewEncoder(f.conn).Encode(msg))
+}
+
+func (f *fakeMpv) propertyChange(id int, name string, data any) {
+	f.send(map[string]any{"event": "property-change", "id": id, "name": name, "data": data})
+}
+
+func (f *fakeMpv) sentCommands() [][]any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][]any(nil), f.commands...)
+}
+
+func newConnectedPlayer(t *testing.T) (*MpvPlayer, *fakeMpv) {
+	socketPath := filepath.Join(t.TempDir(), "mpv.sock")
+	player := NewMpvPlayer(socketPath, domain.Config{}).(*MpvPlayer)
+	fake := newFakeMpv(t, socketPath)
+	require.NoError(t, player.connect())
+	t.Cleanup(func() { player.disconnect() })
+	return player, fake
+}
+
+func receiveEvent(t *testing.T, events <-chan ports.PlayerEvent) ports.PlayerEvent {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for player event")
+		return ports.PlayerEvent{}
+	}
+}
+
+func receiveState(t *testing.T, events <-chan ports.PlayerEvent) ports.PlayerState {
+	event := receiveEvent(t, events)
+	require.Equal(t, ports.PlayerStateChanged, event.Type)
+	return event.State
+}
+
+func TestMpvPlayer_ObservesProperties(t *testing.T) {
+	player, fake := newConnectedPlayer(t)
+
+	commands := fake.sentCommands()
+	require.Len(t, commands, 7, "connect should observe every state property")
+	require.Equal(t, []any{"observe_property", float64(mpvObserveIDPause), "pause"}, commands[0])
+
+	states := player.Subscribe()
+
+	fake.propertyChange(mpvObserveIDPause, "pause", false)
+	require.True(t, receiveState(t, states).IsPlaying)
+
+	fake.propertyChange(mpvObserveIDDur, "duration", 180.0)
+	require.Equal(t, 180.0, receiveState(t, states).Duration)
+
+	fake.propertyChange(mpvObserveIDPos, "time-pos", 12.5)
+	state := receiveState(t, states)
+	require.Equal(t, 12.5, state.Position)
+	require.True(t, state.IsPlaying, "state should accumulate previous properties")
+
+	cached, err := player.GetState()
+	require.NoError(t, err)
+	require.Equal(t, state, cached)
+}
+
+func TestMpvPlayer_ReusesConnection(t *testing.T) {
+	player, fake := newConnectedPlayer(t)
+	observed := len(fake.sentCommands())
+
+	require.NoError(t, player.Seek(5))
+	require.NoError(t, player.ChangeSpeed(0.25))
+
+	commands := fake.sentCommands()[observed:]
+	require.Len(t, commands, 2, "commands should share the observed connection")
+	require.Equal(t, []any{"seek", float64(5), "relative"}, commands[0])
+	require.Equal(t, []any{"add", "speed", 0.25}, commands[1])
+}
+
+func TestMpvPlayer_PublishesTrackEnded(t *testing.T) {
+	player, fake := newConnectedPlayer(t)
+	events := player.Subscribe()
+
+	fake.send(map[string]any{"event": "end-file", "reason": "stop"})
+	fake.send(map[string]any{"event": "end-file", "reason": "eof"})
+
+	event := receiveEvent(t, events)
+	require.Equal(t, ports.PlayerTrackEnded, event.Type, "only end-file with reason eof should end the track")
+	require.NoError(t, event.Err)
+
+	fake.send(map[string]any{"event": "end-file", "reason": "error", "file_error": "loading failed"})
+	event = receiveEvent(t, events)
+	require.Equal(t, ports.PlayerTrackEnded, event.Type, "a track that fails to play should still end")
+	require.EqualError(t, event.Err, "mpv could not play the track: loading failed")
+}
+
+func TestMpvPlayer_RejectedCommand(t *testing.T) {
+	player, fake := newConnectedPlayer(t)
+	fake.mu.Lock()
+	fake.reply = "property unavailable"
+	fake.mu.Unlock()
+
+	require.EqualError(t, player.Seek(5), "mpv rejected [seek 5 relative]: property unavailable")
+}
+
+func TestMpvPlayer_SetLoop(t *testing.T) {
+	player, fake := newConnectedPlayer(t)
+	observed := len(fake.sentCommands())
+
+	events := player.Subscribe()
+
+	require.NoError(t, player.SetLoop(domain.LoopOff, 3))
+	require.NoError(t, player.SetLoop(domain.LoopInfinite, 3))
+	require.NoError(t, player.SetLoop(domain.LoopRepeat, 3))
+
+	commands := fake.sentCommands()[observed:]
+	require.Equal(t, []any{"set_property", "loop-file", "no"}, commands[0])
+	require.Equal(t, []any{"set_property", "loop-file", "inf"}, commands[1])
+	require.Equal(t, []any{"set_property", "loop-file", float64(3)}, commands[2])
+
+	fake.propertyChange(mpvObserveIDLoop, "loop-file", 2.0)
+	state := receiveState(t, events)
+	require.Equal(t, domain.LoopRepeat, state.Loop)
+	require.Equal(t, 2, state.RepeatCount)
+
+	fake.propertyChange(mpvObserveIDLoop, "loop-file", false)
+	require.Equal(t, domain.LoopOff, receiveState(t, events).Loop)
+}
+
+func TestMpvPlayer_Volume(t *testing.T) {
+	player, fake := newConnectedPlayer(t)
+	observed := len(fake.sentCommands())
+	events := player.Subscribe()
+
+	fake.propertyChange(mpvObserveIDVolume, "volume", 60.0)
+	require.Equal(t, 60.0, receiveState(t, events).Volume)
+
+	require.NoError(t, player.ChangeVolume(90))
+	require.NoError(t, player.ChangeVolume(-70))
+	require.NoError(t, player.ToggleMute())
+
+	commands := fake.sentCommands()[observed:]
+	require.Equal(t, []any{"set_property", "volume", float64(domain.MaxVolume)}, commands[0], "volume should be capped at the maximum")
+	require.Equal(t, []any{"set_property", "volume", float64(0)}, commands[1], "volume should not go below 0")
+	require.Equal(t, []any{"cycle", "mute"}, commands[2])
+}
+
+func TestMpvPlayer_VolumeBeforeStart(t *testing.T) {
+	cfg := domain.Config{Playback: domain.PlaybackConfig{Volume: 40}}
+	player := NewMpvPlayer(filepath.Join(t.TempDir(), "mpv.sock"), cfg)
+	events := player.Subscribe()
+
+	require.NoError(t, player.ChangeVolume(-5))
+	require.Equal(t, 35.0, receiveState(t, events).Volume, "volume changes should apply to the next mpv process")
+}
+
+func TestHelperProcess(t *testing.T) {
+	logPath := os.Getenv("YOGO_FAKE_MPV_LOG")
+	if logPath == "" {
+		return
+	}
+
+	var socketPath string
+	for _, arg := range os.Args {
+		if strings.HasPrefix(arg, "--input-ipc-server=") {
+			socketPath = strings.TrimPrefix(arg, "--input-ipc-server=")
+		}
+	}
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		os.Exit(2)
+	}
+	logFile, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		os.Exit(2)
+	}
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			os.Exit(0)
+		}
+		scanner := bufio.NewScanner(conn)
+		encoder := json.NewEncoder(conn)
+		for scanner.Scan() {
+			var cmd MpvCommand
+			if err := json.Unmarshal(scanner.Bytes(), &cmd); err != nil {
+				continue
+			}
+			logFile.Write(append(scanner.Bytes(), '\n'))
+			encoder.Encode(map[string]any{"error": "success", "request_id": cmd.RequestID})
+			if cmd.Command[0] == "loadfile" {
+				encoder.Encode(map[string]any{"event": "file-loaded"})
+			}
+		}
+	}
+}
+
+func TestMpvPlayer_RestartsAfterCrash(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "commands.log")
+	execCommand = func(name string, args ...string) *exec.Cmd {
+		cmd := exec.Command(os.Args[0], append([]string{"-test.run=TestHelperProcess", "--"}, args...)...)
+		cmd.Env = append(os.Environ(), "YOGO_FAKE_MPV_LOG="+logPath)
+		return cmd
+	}
+	defer func() { execCommand = exec.Command }()
+
+	player := NewMpvPlayer(filepath.Join(t.TempDir(), "mpv.sock"), domain.Config{}).(*MpvPlayer)
+	defer player.Close()
+	events := player.Subscribe()
+
+	require.NoError(t, player.Play("https://example.com/song"))
+	player.stateMu.Lock()
+	player.state.Position = 42
+	player.stateMu.Unlock()
+
+	player.mu.Lock()
+	process := player.cmd.Process
+	player.mu.Unlock()
+	require.NoError(t, process.Kill())
+
+	event := receiveEvent(t, events)
+	require.Equal(t, ports.PlayerProcessExited, event.Type)
+	require.ErrorContains(t, event.Err, "restarting")
+
+	event = receiveEvent(t, events)
+	require.Equal(t, ports.PlayerRecovered, event.Type)
+
+	require.Eventually(t, func() bool {
+		commands, _ := os.ReadFile(logPath)
+		return strings.Count(string(commands), `"loadfile","https://example.com/song"`) == 2 &&
+			strings.Contains(string(commands), `"seek",42,"absolute"`)
+	}, 2*time.Second, 20*time.Millisecond, "the song should be reloaded at the last known position")
+}
@@ -0,0 +1,96 @@
+package queue
+
+import (
+	"fmt"
+	"sync"
+	"yogo/internal/domain"
+	"yogo/internal/ports"
+)
+
+type PersistentQueue struct {
+	mu      sync.Mutex
+	songs   []domain.Song
+	storage ports.StorageService
+}
+
+func NewPersistentQueue(storage ports.StorageService) (ports.QueueService, error) {
+	songs, err := storage.LoadQueue()
+	if err != nil {
+		return nil, err
+	}
+	return &PersistentQueue{songs: songs, storage: storage}, nil
+}
+
+func (q *PersistentQueue) save() error {
+	return q.storage.SaveQueue(q.songs)
+}
+
+func (q *PersistentQueue) checkIndex(index int) error {
+	if index < 0 || index >= len(q.songs) {
+		return fmt.Errorf("queue index %d out of range", index)
+	}
+	return nil
+}
+
+func (q *PersistentQueue) Enqueue(song domain.Song) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.songs = append(q.songs, song)
+	return q.save()
+}
+
+func (q *PersistentQueue) PlayNext(song domain.Song) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.songs = append([]domain.Song{song}, q.songs...)
+	return q.save()
+}
+
+func (q *PersistentQueue) Next() (domain.Song, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.songs) == 0 {
+		return domain.Song{}, false, nil
+	}
+	song := q.songs[0]
+	q.songs = q.songs[1:]
+	return song, true, q.save()
+}
+
+func (q *PersistentQueue) Remove(index int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.checkIndex(index); err != nil {
+		return err
+	}
+	q.songs = append(q.songs[:index], q.songs[index+1:]...)
+	return q.save()
+}
+
+func (q *PersistentQueue) Move(from, to int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.checkIndex(from); err != nil {
+		return err
+	}
+	if err := q.checkIndex(to); err != nil {
+		return err
+	}
+	song := q.songs[from]
+	q.songs = append(q.songs[:from], q.songs[from+1:]...)
+	q.songs = append(q.songs[:to], append([]domain.Song{song}, q.songs[to:]...)...)
+	return q.save()
+}
+
+func (q *PersistentQueue) Clear() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.songs = nil
+	return q.save()
+}
+
+func (q *PersistentQueue) List() []domain.Song {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]domain.Song(nil), q.songs...)
+}
@@ -0,0 +1,56 @@
+package queue
+
+import (
+	"path/filepath"
+	"testing"
+	"yogo/internal/domain"
+	"yogo/internal/services/storage"
+
+	"github.com/stretchr/testify/require"
+)
+
+func songIDs(songs []domain.Song) []string {
+	ids := make([]string, len(songs))
+	for i, song := range songs {
+		ids[i] = song.ID
+	}
+	return ids
+}
+
+func TestPersistentQueue(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	store, err := storage.NewBboltStore(dbPath)
+	require.NoError(t, err)
+	defer store.Close()
+
+	q, err := NewPersistentQueue(store)
+	require.NoError(t, err)
+
+	require.NoError(t, q.Enqueue(domain.Song{ID: "a"}))
+	require.NoError(t, q.Enqueue(domain.Song{ID: "b"}))
+	require.NoError(t, q.PlayNext(domain.Song{ID: "c"}))
+	require.Equal(t, []string{"c", "a", "b"}, songIDs(q.List()))
+
+	require.NoError(t, q.Move(0, 2))
+	require.Equal(t, []string{"a", "b", "c"}, songIDs(q.List()))
+	require.NoError(t, q.Move(2, 1))
+	require.Equal(t, []string{"a", "c", "b"}, songIDs(q.List()))
+	require.Error(t, q.Move(0, 3), "Moving outside the queue should fail")
+
+	require.NoError(t, q.Remove(1))
+	require.Equal(t, []string{"a", "b"}, songIDs(q.List()))
+
+	song, ok, err := q.Next()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "a", song.ID)
+
+	reloaded, err := NewPersistentQueue(store)
+	require.NoError(t, err)
+	require.Equal(t, []string{"b"}, songIDs(reloaded.List()), "The queue should be persisted after every change")
+
+	require.NoError(t, q.Clear())
+	_, ok, err = q.Next()
+	require.NoError(t, err)
+	require.False(t, ok, "An empty queue should have no next song")
+}
@@ -0,0 +1,83 @@
+package radio
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"yogo/internal/domain"
+	"yogo/internal/ports"
+)
+
+const (
+	batchSize     = 10
+	recentHistory = 50
+)
+
+var ErrNoRelated = errors.New("no related songs that were not played recently")
+
+type Radio struct {
+	mu      sync.Mutex
+	enabled bool
+	yt      ports.YoutubeService
+	storage ports.StorageService
+	queue   ports.QueueService
+}
+
+func NewRadio(yt ports.YoutubeService, storage ports.StorageService, queue ports.QueueService, enabled bool) *Radio {
+	return &Radio{enabled: enabled, yt: yt, storage: storage, queue: queue}
+}
+
+func (r *Radio) Enabled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enabled
+}
+
+func (r *Radio) SetEnabled(enabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.enabled = enabled
+	return nil
+}
+
+func (r *Radio) Fill(seed domain.Song) (int, error) {
+	mix, err := r.yt.GetPlaylist(mixURL(seed.ID))
+	if err != nil {
+		return 0, fmt.Errorf("could not load related songs: %w", err)
+	}
+
+	seen := map[string]bool{seed.ID: true}
+	history, err := r.storage.GetHistory(recentHistory)
+	if err != nil {
+		return 0, err
+	}
+	for _, entry := range history {
+		seen[entry.Song.ID] = true
+	}
+	for _, song := range r.queue.List() {
+		seen[song.ID] = true
+	}
+
+	added := 0
+	for _, song := range mix.Songs {
+		if added == batchSize {
+			break
+		}
+		if seen[song.ID] {
+			continue
+		}
+		seen[song.ID] = true
+		if err := r.queue.Enqueue(song); err != nil {
+			return added, err
+		}
+		added++
+	}
+	if added == 0 {
+		return 0, ErrNoRelated
+	}
+	return added, nil
+}
+
+func mixURL(videoID string) string {
+	return fmt.Sprintf("https://www.youtube.com/watch?v=%s&list=RD%s", videoID, videoID)
+}
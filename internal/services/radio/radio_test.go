@@ -0,0 +1,63 @@
+package radio
+
+import (
+	"path/filepath"
+	"testing"
+	"yogo/internal/domain"
+	"yogo/internal/ports"
+	"yogo/internal/services/queue"
+	"yogo/internal/services/storage"
+
+	"github.com/stretchr/testify/require"
+)
+
+type stubYoutube struct {
+	ports.YoutubeService
+	requested string
+	songs     []domain.Song
+}
+
+func (s *stubYoutube) GetPlaylist(url string) (domain.Playlist, error) {
+	s.requested = url
+	return domain.Playlist{Songs: s.songs}, nil
+}
+
+func songs(ids ...string) []domain.Song {
+	result := make([]domain.Song, len(ids))
+	for i, id := range ids {
+		result[i] = domain.Song{ID: id}
+	}
+	return result
+}
+
+func TestFillSkipsRecentSongs(t *testing.T) {
+	store, err := storage.NewBboltStore(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	q, err := queue.NewPersistentQueue(store)
+	require.NoError(t, err)
+	require.NoError(t, store.AddToHistory(domain.HistoryEntry{Song: domain.Song{ID: "played"}}))
+	require.NoError(t, q.Enqueue(domain.Song{ID: "queued"}))
+
+	yt := &stubYoutube{songs: songs("seed", "played", "fresh1", "queued", "fresh2", "fresh1")}
+	radio := NewRadio(yt, store, q, false)
+	require.False(t, radio.Enabled())
+	require.NoError(t, radio.SetEnabled(true))
+	require.True(t, radio.Enabled())
+
+	added, err := radio.Fill(domain.Song{ID: "seed"})
+	require.NoError(t, err)
+	require.Equal(t, 2, added)
+	require.Equal(t, "https://www.youtube.com/watch?v=seed&list=RDseed", yt.requested)
+
+	var ids []string
+	for _, song := range q.List() {
+		ids = append(ids, song.ID)
+	}
+	require.Equal(t, []string{"queued", "fresh1", "fresh2"}, ids)
+
+	yt.songs = songs("seed", "played")
+	_, err = radio.Fill(domain.Song{ID: "seed"})
+	require.ErrorIs(t, err, ErrNoRelated)
+}
@@ -0,0 +1,175 @@
+package scrobble
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"yogo/internal/domain"
+)
+
+const lastFMName = "lastfm"
+
+var lastFMTransientErrors = map[int]bool{
+	9:  true, // Invalid session key, permanent when it comes from the configuration
+	11: true, // Service offline
+	16: true, // Temporarily unavailable
+	29: true, // Rate limit exceeded
+}
+
+type lastFMResponse struct {
+	Error   int    `json:"error"`
+	Message string `json:"message"`
+	Session struct {
+		Key string `json:"key"`
+	} `json:"session"`
+}
+
+type lastFM struct {
+	cfg    domain.LastFMConfig
+	client *http.Client
+
+	mu         sync.Mutex
+	sessionKey string
+}
+
+func newLastFM(cfg domain.LastFMConfig, client *http.Client) *lastFM {
+	return &lastFM{cfg: cfg, client: client, sessionKey: cfg.SessionKey}
+}
+
+func (l *lastFM) name() string {
+	return lastFMName
+}
+
+func (l *lastFM) nowPlaying(scrobble domain.Scrobble) error {
+	params, err := trackParams(scrobble)
+	if err != nil {
+		return err
+	}
+	return l.callWithSession("track.updateNowPlaying", params)
+}
+
+func (l *lastFM) scrobble(scrobble domain.Scrobble) error {
+	params, err := trackParams(scrobble)
+	if err != nil {
+		return err
+	}
+	params.Set("timestamp", strconv.FormatInt(scrobble.StartedAt.Unix(), 10))
+	return l.callWithSession("track.scrobble", params)
+}
+
+func trackParams(scrobble domain.Scrobble) (url.Values, error) {
+	artist := artist(scrobble.Song)
+	if artist == "" {
+		return nil, &permanentError{errors.New("the song has no artist")}
+	}
+	params := url.Values{}
+	params.Set("artist", artist)
+	params.Set("track", scrobble.Song.Title)
+	if scrobble.Duration > 0 {
+		params.Set("duration", strconv.Itoa(scrobble.Duration))
+	}
+	return params, nil
+}
+
+func (l *lastFM) callWithSession(method string, params url.Values) error {
+	sessionKey, err := l.session()
+	if err != nil {
+		return err
+	}
+	params.Set("sk", sessionKey)
+	_, err = l.call(method, params)
+
+	var apiErr *lastFMError
+	if errors.As(err, &apiErr) && apiErr.code == 9 {
+		if l.cfg.SessionKey != "" {
+			return &permanentError{err}
+		}
+		l.mu.Lock()
+		l.sessionKey = ""
+		l.mu.Unlock()
+	}
+	return err
+}
+
+func (l *lastFM) session() (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.sessionKey != "" {
+		return l.sessionKey, nil
+	}
+
+	params := url.Values{}
+	params.Set("username", l.cfg.Username)
+	params.Set("password", l.cfg.Password)
+	resp, err := l.call("auth.getMobileSession", params)
+	if err != nil {
+		return "", fmt.Errorf("could not log in to Last.fm: %v", err)
+	}
+	l.sessionKey = resp.Session.Key
+	return l.sessionKey, nil
+}
+
+func (l *lastFM) call(method string, params url.Values) (lastFMResponse, error) {
+	var result lastFMResponse
+	params.Set("method", method)
+	params.Set("api_key", l.cfg.APIKey)
+	params.Set("api_sig", l.signature(params))
+	params.Set("format", "json")
+
+	resp, err := l.client.PostForm(l.cfg.URL, params)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return result, fmt.Errorf("Last.fm returned %s", resp.Status)
+		}
+		return result, fmt.Errorf("invalid Last.fm response: %w", err)
+	}
+	if result.Error != 0 {
+		err := &lastFMError{code: result.Error, message: result.Message}
+		if lastFMTransientErrors[result.Error] {
+			return result, err
+		}
+		return result, &permanentError{err}
+	}
+	return result, nil
+}
+
+func (l *lastFM) signature(params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		if key != "format" && key != "callback" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(key)
+		b.WriteString(params.Get(key))
+	}
+	b.WriteString(l.cfg.APISecret)
+	sum := md5.Sum([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+type lastFMError struct {
+	code    int
+	message string
+}
+
+func (e *lastFMError) Error() string {
+	return fmt.Sprintf("Last.fm error %d: %s", e.code, e.message)
+}
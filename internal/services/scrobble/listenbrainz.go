@@ -0,0 +1,107 @@
+package scrobble
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"yogo/internal/domain"
+)
+
+const (
+	listenBrainzName = "listenbrainz"
+	maxErrorBody     = 512
+)
+
+type listenBrainzPayload struct {
+	ListenType string               `json:"listen_type"`
+	Payload    []listenBrainzListen `json:"payload"`
+}
+
+type listenBrainzListen struct {
+	ListenedAt    int64                     `json:"listened_at,omitempty"`
+	TrackMetadata listenBrainzTrackMetadata `json:"track_metadata"`
+}
+
+type listenBrainzTrackMetadata struct {
+	ArtistName     string         `json:"artist_name"`
+	TrackName      string         `json:"track_name"`
+	AdditionalInfo map[string]any `json:"additional_info"`
+}
+
+type listenBrainz struct {
+	cfg    domain.ListenBrainzConfig
+	client *http.Client
+}
+
+func newListenBrainz(cfg domain.ListenBrainzConfig, client *http.Client) *listenBrainz {
+	return &listenBrainz{cfg: cfg, client: client}
+}
+
+func (l *listenBrainz) name() string {
+	return listenBrainzName
+}
+
+func (l *listenBrainz) nowPlaying(scrobble domain.Scrobble) error {
+	return l.submit("playing_now", scrobble)
+}
+
+func (l *listenBrainz) scrobble(scrobble domain.Scrobble) error {
+	return l.submit("single", scrobble)
+}
+
+func (l *listenBrainz) submit(listenType string, scrobble domain.Scrobble) error {
+	artist := artist(scrobble.Song)
+	if artist == "" {
+		return &permanentError{fmt.Errorf("the song has no artist")}
+	}
+
+	info := map[string]any{
+		"media_player":      "yogo",
+		"submission_client": "yogo",
+		"music_service":     "youtube.com",
+		"origin_url":        "https://www.youtube.com/watch?v=" + scrobble.Song.ID,
+	}
+	if scrobble.Duration > 0 {
+		info["duration_ms"] = scrobble.Duration * 1000
+	}
+	listen := listenBrainzListen{
+		TrackMetadata: listenBrainzTrackMetadata{
+			ArtistName:     artist,
+			TrackName:      scrobble.Song.Title,
+			AdditionalInfo: info,
+		},
+	}
+	if listenType != "playing_now" {
+		listen.ListenedAt = scrobble.StartedAt.Unix()
+	}
+	body, err := json.Marshal(listenBrainzPayload{ListenType: listenType, Payload: []listenBrainzListen{listen}})
+	if err != nil {
+		return &permanentError{err}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(l.cfg.URL, "/")+"/1/submit-listens", bytes.NewReader(body))
+	if err != nil {
+		return &permanentError{err}
+	}
+	req.Header.Set("Authorization", "Token "+l.cfg.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	err = fmt.Errorf("ListenBrainz returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	if resp.StatusCode == http.StatusBadRequest {
+		return &permanentError{err}
+	}
+	return err
+}
@@ -0,0 +1,253 @@
+package scrobble
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"yogo/internal/domain"
+	"yogo/internal/logger"
+	"yogo/internal/ports"
+)
+
+const (
+	minDuration      = 30
+	maxThreshold     = 4 * 60
+	restartTolerance = 5
+	requestTimeout   = 10 * time.Second
+	retryInterval    = 5 * time.Minute
+	retryBatchSize   = 50
+	jobBufferSize    = 32
+)
+
+type backend interface {
+	name() string
+	nowPlaying(scrobble domain.Scrobble) error
+	scrobble(scrobble domain.Scrobble) error
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+func isPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+type job struct {
+	scrobble   domain.Scrobble
+	nowPlaying bool
+}
+
+type play struct {
+	song         domain.Song
+	startedAt    time.Time
+	lastPosition float64
+	scrobbled    bool
+}
+
+type Scrobbler struct {
+	backends      map[string]backend
+	storage       ports.StorageService
+	retryInterval time.Duration
+	now           func() time.Time
+
+	current play
+	jobs    chan job
+
+	done    chan struct{}
+	stopped sync.WaitGroup
+}
+
+func NewScrobbler(cfg domain.ScrobblingConfig, bus ports.EventBus, storage ports.StorageService) *Scrobbler {
+	client := &http.Client{Timeout: requestTimeout}
+	var backends []backend
+	if cfg.LastFM.Enabled {
+		backends = append(backends, newLastFM(cfg.LastFM, client))
+	}
+	if cfg.ListenBrainz.Enabled {
+		backends = append(backends, newListenBrainz(cfg.ListenBrainz, client))
+	}
+	return newScrobbler(backends, bus, storage, retryInterval)
+}
+
+func newScrobbler(backends []backend, bus ports.EventBus, storage ports.StorageService, retryInterval time.Duration) *Scrobbler {
+	s := &Scrobbler{
+		backends:      make(map[string]backend),
+		storage:       storage,
+		retryInterval: retryInterval,
+		now:           time.Now,
+		jobs:          make(chan job, jobBufferSize),
+		done:          make(chan struct{}),
+	}
+	for _, b := range backends {
+		s.backends[b.name()] = b
+	}
+	s.stopped.Add(2)
+	go s.watch(bus.Subscribe())
+	go s.work()
+	return s
+}
+
+func (s *Scrobbler) watch(events <-chan ports.Event) {
+	defer s.stopped.Done()
+	for {
+		select {
+		case <-s.done:
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			s.handle(event)
+		}
+	}
+}
+
+func (s *Scrobbler) handle(event ports.Event) {
+	switch event := event.(type) {
+	case ports.SongStartedEvent:
+		s.current = play{song: event.Song, startedAt: s.now()}
+		s.submit(false, 0)
+	case ports.PlaybackStoppedEvent:
+		s.current = play{}
+	case ports.PlayerStateChangedEvent:
+		if s.current.song.ID == "" {
+			return
+		}
+		state := event.State
+		if s.current.scrobbled && state.Position < restartTolerance && state.Position < s.current.lastPosition-restartTolerance {
+			s.current = play{song: s.current.song, startedAt: s.now().Add(-seconds(state.Position))}
+			s.submit(false, state.Duration)
+		}
+		s.current.lastPosition = state.Position
+		if !s.current.scrobbled && state.Duration >= minDuration && state.Position >= threshold(state.Duration) {
+			s.current.scrobbled = true
+			s.submit(true, state.Duration)
+		}
+	}
+}
+
+func threshold(duration float64) float64 {
+	return math.Min(duration/2, maxThreshold)
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
+
+func (s *Scrobbler) submit(scrobble bool, duration float64) {
+	for name := range s.backends {
+		j := job{
+			nowPlaying: !scrobble,
+			scrobble: domain.Scrobble{
+				Service:   name,
+				Song:      s.current.song,
+				StartedAt: s.current.startedAt,
+				Duration:  int(math.Round(duration)),
+			},
+		}
+		select {
+		case s.jobs <- j:
+		default:
+			logger.Log.Warn().Str("service", name).Msg("Scrobbler is not keeping up, dropping submission")
+			if scrobble {
+				s.store(j.scrobble)
+			}
+		}
+	}
+}
+
+func (s *Scrobbler) work() {
+	defer s.stopped.Done()
+	ticker := time.NewTicker(s.retryInterval)
+	defer ticker.Stop()
+
+	s.retryPending()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.retryPending()
+		case j := <-s.jobs:
+			s.run(j)
+		}
+	}
+}
+
+func (s *Scrobbler) run(j job) {
+	b := s.backends[j.scrobble.Service]
+	if j.nowPlaying {
+		if err := b.nowPlaying(j.scrobble); err != nil {
+			logger.Log.Warn().Err(err).Str("service", b.name()).Msg("Could not update now playing")
+		}
+		return
+	}
+
+	err := b.scrobble(j.scrobble)
+	switch {
+	case err == nil:
+		logger.Log.Debug().Str("service", b.name()).Str("songID", j.scrobble.Song.ID).Msg("Scrobbled")
+		s.retryPending()
+	case isPermanent(err):
+		logger.Log.Error().Err(err).Str("service", b.name()).Str("songID", j.scrobble.Song.ID).Msg("Scrobble was rejected")
+	default:
+		logger.Log.Warn().Err(err).Str("service", b.name()).Str("songID", j.scrobble.Song.ID).Msg("Could not scrobble, will retry later")
+		s.store(j.scrobble)
+	}
+}
+
+func (s *Scrobbler) store(scrobble domain.Scrobble) {
+	if err := s.storage.AddPendingScrobble(scrobble); err != nil {
+		logger.Log.Error().Err(err).Str("songID", scrobble.Song.ID).Msg("Could not save the scrobble for later")
+	}
+}
+
+func (s *Scrobbler) retryPending() {
+	pending, err := s.storage.GetPendingScrobbles(retryBatchSize)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("Could not load pending scrobbles")
+		return
+	}
+
+	failed := make(map[string]bool)
+	for _, scrobble := range pending {
+		b, ok := s.backends[scrobble.Service]
+		if !ok || failed[scrobble.Service] {
+			continue
+		}
+		err := b.scrobble(scrobble)
+		if err != nil && !isPermanent(err) {
+			logger.Log.Warn().Err(err).Str("service", b.name()).Msg("Could not submit pending scrobbles")
+			failed[scrobble.Service] = true
+			continue
+		}
+		if err != nil {
+			logger.Log.Error().Err(err).Str("service", b.name()).Str("songID", scrobble.Song.ID).Msg("Pending scrobble was rejected")
+		}
+		if err := s.storage.DeletePendingScrobble(scrobble.ID); err != nil {
+			logger.Log.Error().Err(err).Msg("Could not remove the pending scrobble")
+		}
+	}
+}
+
+func (s *Scrobbler) Close() error {
+	close(s.done)
+	s.stopped.Wait()
+	return nil
+}
+
+func artist(song domain.Song) string {
+	artists := make([]string, len(song.Artists))
+	for i, name := range song.Artists {
+		artists[i] = strings.TrimSuffix(name, " - Topic")
+	}
+	return strings.Join(artists, ", ")
+}
@@ -0,0 +1,265 @@
+package scrobble
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+	"yogo/internal/domain"
+	"yogo/internal/ports"
+	"yogo/internal/services/events"
+	"yogo/internal/services/storage"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBackend struct {
+	mu          sync.Mutex
+	err         error
+	nowPlayings []domain.Scrobble
+	scrobbles   []domain.Scrobble
+}
+
+func (b *fakeBackend) name() string { return "fake" }
+
+func (b *fakeBackend) nowPlaying(scrobble domain.Scrobble) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nowPlayings = append(b.nowPlayings, scrobble)
+	return nil
+}
+
+func (b *fakeBackend) scrobble(scrobble domain.Scrobble) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return b.err
+	}
+	b.scrobbles = append(b.scrobbles, scrobble)
+	return nil
+}
+
+func (b *fakeBackend) setError(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.err = err
+}
+
+func (b *fakeBackend) counts() (int, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.nowPlayings), len(b.scrobbles)
+}
+
+func newTestScrobbler(t *testing.T, retryInterval time.Duration) (*events.Bus, *fakeBackend, ports.StorageService) {
+	store, err := storage.NewBboltStore(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	bus := events.NewBus()
+	fake := &fakeBackend{}
+	scrobbler := newScrobbler([]backend{fake}, bus, store, retryInterval)
+	t.Cleanup(func() {
+		scrobbler.Close()
+		bus.Close()
+		store.Close()
+	})
+	return bus, fake, store
+}
+
+func progress(position, duration float64) ports.Event {
+	return ports.PlayerStateChangedEvent{State: ports.PlayerState{IsPlaying: true, Position: position, Duration: duration}}
+}
+
+func waitForCounts(t *testing.T, backend *fakeBackend, nowPlayings, scrobbles int) {
+	require.Eventually(t, func() bool {
+		n, s := backend.counts()
+		return n == nowPlayings && s == scrobbles
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestScrobbler_Threshold(t *testing.T) {
+	bus, backend, _ := newTestScrobbler(t, time.Hour)
+	song := domain.Song{ID: "song1_id", Title: "Song 1", Artists: []string{"Artist 1"}}
+
+	bus.Publish(ports.SongStartedEvent{Song: song})
+	bus.Publish(progress(10, 600))
+	bus.Publish(progress(200, 600))
+	waitForCounts(t, backend, 1, 0)
+
+	bus.Publish(progress(240, 600))
+	bus.Publish(progress(300, 600))
+	waitForCounts(t, backend, 1, 1)
+	require.Equal(t, song, backend.scrobbles[0].Song)
+	require.Equal(t, 600, backend.scrobbles[0].Duration)
+
+	bus.Publish(progress(1, 600))
+	bus.Publish(progress(250, 600))
+	waitForCounts(t, backend, 2, 2)
+
+	bus.Publish(ports.SongStartedEvent{Song: domain.Song{ID: "short_id", Title: "Jingle", Artists: []string{"Artist 1"}}})
+	bus.Publish(progress(20, 25))
+	bus.Publish(ports.SongStartedEvent{Song: song})
+	bus.Publish(progress(60, 100))
+	waitForCounts(t, backend, 4, 3)
+}
+
+func TestScrobbler_OfflineQueue(t *testing.T) {
+	bus, backend, store := newTestScrobbler(t, 20*time.Millisecond)
+	backend.setError(errors.New("connection refused"))
+
+	bus.Publish(ports.SongStartedEvent{Song: domain.Song{ID: "song1_id", Title: "Song 1", Artists: []string{"Artist 1"}}})
+	bus.Publish(progress(100, 120))
+	require.Eventually(t, func() bool {
+		pending, err := store.GetPendingScrobbles(10)
+		return err == nil && len(pending) == 1
+	}, time.Second, 10*time.Millisecond, "Failed scrobbles should be stored")
+
+	backend.setError(nil)
+	require.Eventually(t, func() bool {
+		pending, err := store.GetPendingScrobbles(10)
+		return err == nil && len(pending) == 0
+	}, time.Second, 10*time.Millisecond, "Stored scrobbles should be retried")
+	waitForCounts(t, backend, 1, 1)
+	require.Equal(t, "fake", backend.scrobbles[0].Service)
+
+	backend.setError(&permanentError{errors.New("invalid track")})
+	bus.Publish(ports.SongStartedEvent{Song: domain.Song{ID: "song2_id", Title: "Song 2", Artists: []string{"Artist 1"}}})
+	bus.Publish(progress(100, 120))
+	waitForCounts(t, backend, 2, 1)
+	time.Sleep(50 * time.Millisecond)
+	pending, err := store.GetPendingScrobbles(10)
+	require.NoError(t, err)
+	require.Empty(t, pending, "Rejected scrobbles should not be retried")
+}
+
+func TestLastFM(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	var failures int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		mu.Lock()
+		defer mu.Unlock()
+		form := r.PostForm
+		signed := url.Values{}
+		for key, values := range form {
+			if key != "api_sig" {
+				signed[key] = values
+			}
+		}
+		expected := (&lastFM{cfg: domain.LastFMConfig{APISecret: "secret"}}).signature(signed)
+		if form.Get("api_sig") != expected || form.Get("api_key") != "key" {
+			json.NewEncoder(w).Encode(lastFMResponse{Error: 13, Message: "Invalid method signature supplied"})
+			return
+		}
+		method := form.Get("method")
+		calls = append(calls, method)
+		switch method {
+		case "auth.getMobileSession":
+			w.Write([]byte(`{"session":{"name":"user","key":"session-key"}}`))
+		case "track.scrobble":
+			if form.Get("sk") == "revoked" {
+				json.NewEncoder(w).Encode(lastFMResponse{Error: 9, Message: "Invalid session key"})
+				return
+			}
+			if failures > 0 {
+				failures--
+				json.NewEncoder(w).Encode(lastFMResponse{Error: 16, Message: "Temporarily unavailable"})
+				return
+			}
+			require.Equal(t, "session-key", form.Get("sk"))
+			require.Equal(t, "Artist 1", form.Get("artist"))
+			require.Equal(t, "Song 1", form.Get("track"))
+			require.Equal(t, "1714564800", form.Get("timestamp"))
+			require.Equal(t, "213", form.Get("duration"))
+			w.Write([]byte(`{"scrobbles":{"@attr":{"accepted":1,"ignored":0}}}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	cfg := domain.LastFMConfig{Enabled: true, APIKey: "key", APISecret: "secret", Username: "user", Password: "password", URL: server.URL}
+	client := newLastFM(cfg, server.Client())
+	scrobble := domain.Scrobble{
+		Song:      domain.Song{ID: "song1_id", Title: "Song 1", Artists: []string{"Artist 1 - Topic"}},
+		StartedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Duration:  213,
+	}
+
+	require.NoError(t, client.nowPlaying(scrobble))
+	require.NoError(t, client.scrobble(scrobble))
+	failures = 1
+	err := client.scrobble(scrobble)
+	require.Error(t, err)
+	require.False(t, isPermanent(err), "Temporary outages should be retried")
+	require.Equal(t, []string{"auth.getMobileSession", "track.updateNowPlaying", "track.scrobble", "track.scrobble"}, calls)
+
+	client = newLastFM(domain.LastFMConfig{APIKey: "key", APISecret: "wrong", SessionKey: "session-key", URL: server.URL}, server.Client())
+	err = client.scrobble(scrobble)
+	require.True(t, isPermanent(err), "Rejected requests should not be retried")
+
+	err = client.scrobble(domain.Scrobble{Song: domain.Song{ID: "song1_id", Title: "Song 1"}})
+	require.True(t, isPermanent(err))
+
+	client = newLastFM(domain.LastFMConfig{APIKey: "key", APISecret: "secret", SessionKey: "revoked", URL: server.URL}, server.Client())
+	err = client.scrobble(scrobble)
+	require.True(t, isPermanent(err), "A configured session key that is rejected cannot be renewed")
+}
+
+func TestListenBrainz(t *testing.T) {
+	var mu sync.Mutex
+	var received []listenBrainzPayload
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		require.Equal(t, "/1/submit-listens", r.URL.Path)
+		if r.Header.Get("Authorization") != "Token token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var payload listenBrainzPayload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		received = append(received, payload)
+		w.WriteHeader(status)
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	client := newListenBrainz(domain.ListenBrainzConfig{Enabled: true, Token: "token", URL: server.URL + "/"}, server.Client())
+	scrobble := domain.Scrobble{
+		Song:      domain.Song{ID: "song1_id", Title: "Song 1", Artists: []string{"Artist 1"}},
+		StartedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Duration:  213,
+	}
+
+	require.NoError(t, client.nowPlaying(scrobble))
+	require.NoError(t, client.scrobble(scrobble))
+	require.Len(t, received, 2)
+	require.Equal(t, "playing_now", received[0].ListenType)
+	require.Zero(t, received[0].Payload[0].ListenedAt)
+	require.Equal(t, "single", received[1].ListenType)
+	listen := received[1].Payload[0]
+	require.Equal(t, int64(1714564800), listen.ListenedAt)
+	require.Equal(t, "Artist 1", listen.TrackMetadata.ArtistName)
+	require.Equal(t, "Song 1", listen.TrackMetadata.TrackName)
+	require.Equal(t, 213000.0, listen.TrackMetadata.AdditionalInfo["duration_ms"])
+
+	status = http.StatusServiceUnavailable
+	err := client.scrobble(scrobble)
+	require.Error(t, err)
+	require.False(t, isPermanent(err))
+
+	status = http.StatusBadRequest
+	require.True(t, isPermanent(client.scrobble(scrobble)))
+
+	client = newListenBrainz(domain.ListenBrainzConfig{Token: "wrong", URL: server.URL}, server.Client())
+	err = client.scrobble(scrobble)
+	require.Error(t, err)
+	require.False(t, isPermanent(err), "A wrong token should keep the scrobbles until it is fixed")
+}
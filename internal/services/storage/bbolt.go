@@ -2,9 +2,12 @@ package storage
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"sort"
+	"strings"
 	"time"
 	"yogo/internal/domain"
 	"yogo/internal/ports"
@@ -12,7 +15,16 @@ import (
 	"go.etcd.io/bbolt"
 )
 
-var historyBucket = []byte("history")
+var (
+	historyBucket  = []byte("history")
+	queueBucket    = []byte("queue")
+	queueKey       = []byte("songs")
+	scrobbleBucket = []byte("scrobbles")
+	searchBucket   = []byte("searches")
+	searchKey      = []byte("queries")
+)
+
+const maxSearchQueries = 100
 
 type BboltStore struct {
 	db *bbolt.DB
@@ -26,11 +38,15 @@ func NewBboltStore(dbPath string) (ports.StorageService, error) {
 	}
 
 	err = db.Update(func(tx *bbolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists(historyBucket)
-		return err
+		for _, bucket := range [][]byte{historyBucket, queueBucket, scrobbleBucket, searchBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return fmt.Errorf("could not create %s bucket: %w", bucket, err)
+			}
+		}
+		return nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("could not create history bucket: %w", err)
+		return nil, err
 	}
 
 	return &BboltStore{db: db}, nil
@@ -56,7 +72,11 @@ func (s *BboltStore) AddToHistory(entry domain.HistoryEntry) error {
 	return s.db.Update(func(tx *bbolt.Tx) error {
 		b := tx.Bucket(historyBucket)
 
-		if oldKey, _ := s.findOldKeyForSong(b, entry.Song.ID); oldKey != nil {
+		if oldKey, oldVal := s.findOldKeyForSong(b, entry.Song.ID); oldKey != nil {
+			var old domain.HistoryEntry
+			if err := json.Unmarshal(oldVal, &old); err == nil {
+				entry.Song = entry.Song.WithMetadataFrom(old.Song)
+			}
 			if err := b.Delete(oldKey); err != nil {
 				return err
 			}
@@ -147,6 +167,124 @@ func (s *BboltStore) GetHistory(limit int) ([]domain.HistoryEntry, error) {
 	return entries, nil
 }
 
+func (s *BboltStore) SaveQueue(songs []domain.Song) error {
+	value, err := json.Marshal(songs)
+	if err != nil {
+		return fmt.Errorf("error serializing queue: %w", err)
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(queueBucket).Put(queueKey, value)
+	})
+}
+
+func (s *BboltStore) LoadQueue() ([]domain.Song, error) {
+	var songs []domain.Song
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		value := tx.Bucket(queueBucket).Get(queueKey)
+		if value == nil {
+			return nil
+		}
+		return json.Unmarshal(value, &songs)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not load queue: %w", err)
+	}
+	return songs, nil
+}
+
+func scrobbleKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+func (s *BboltStore) AddPendingScrobble(scrobble domain.Scrobble) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(scrobbleBucket)
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		scrobble.ID = id
+		value, err := json.Marshal(scrobble)
+		if err != nil {
+			return fmt.Errorf("error serializing scrobble: %w", err)
+		}
+		return b.Put(scrobbleKey(id), value)
+	})
+}
+
+func (s *BboltStore) GetPendingScrobbles(limit int) ([]domain.Scrobble, error) {
+	var scrobbles []domain.Scrobble
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(scrobbleBucket).Cursor()
+		for k, v := c.First(); k != nil && len(scrobbles) < limit; k, v = c.Next() {
+			var scrobble domain.Scrobble
+			if err := json.Unmarshal(v, &scrobble); err != nil {
+				return err
+			}
+			scrobbles = append(scrobbles, scrobble)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not load pending scrobbles: %w", err)
+	}
+	return scrobbles, nil
+}
+
+func (s *BboltStore) DeletePendingScrobble(id uint64) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(scrobbleBucket).Delete(scrobbleKey(id))
+	})
+}
+
+func (s *BboltStore) AddSearchQuery(query string) error {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(searchBucket)
+		var queries []string
+		if value := b.Get(searchKey); value != nil {
+			if err := json.Unmarshal(value, &queries); err != nil {
+				return err
+			}
+		}
+
+		queries = slices.DeleteFunc(queries, func(q string) bool { return strings.EqualFold(q, query) })
+		queries = append([]string{query}, queries...)
+		if len(queries) > maxSearchQueries {
+			queries = queries[:maxSearchQueries]
+		}
+
+		value, err := json.Marshal(queries)
+		if err != nil {
+			return fmt.Errorf("error serializing search queries: %w", err)
+		}
+		return b.Put(searchKey, value)
+	})
+}
+
+func (s *BboltStore) GetSearchQueries(limit int) ([]string, error) {
+	var queries []string
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		value := tx.Bucket(searchBucket).Get(searchKey)
+		if value == nil {
+			return nil
+		}
+		return json.Unmarshal(value, &queries)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not load search queries: %w", err)
+	}
+	if len(queries) > limit {
+		queries = queries[:limit]
+	}
+	return queries, nil
+}
+
 func (s *BboltStore) Close() error {
 	return s.db.Close()
 }
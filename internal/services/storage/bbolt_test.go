@@ -70,3 +70,99 @@ func TestBboltStore_History(t *testing.T) {
 	require.Equal(t, 120, historyAfterPositionUpdate[0].ResumeAt, "ResumeAt should be updated")
 	require.Equal(t, "song1_id", historyAfterPositionUpdate[1].Song.ID)
 }
+
+func TestBboltStore_HistoryKeepsMetadata(t *testing.T) {
+	store, err := NewBboltStore(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	song := domain.Song{ID: "song1_id", Title: "Song 1", Artists: []string{"Artist"}, Duration: 215, ChannelID: "UC1", Views: 1000, UploadDate: "2023-01-15"}
+	require.NoError(t, store.AddToHistory(domain.HistoryEntry{Song: song}))
+
+	require.NoError(t, store.AddToHistory(domain.HistoryEntry{Song: domain.Song{ID: "song1_id", Title: "Song 1", Views: 2000}}))
+
+	history, err := store.GetHistory(10)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, 215, history[0].Song.Duration)
+	require.Equal(t, "UC1", history[0].Song.ChannelID)
+	require.Equal(t, int64(2000), history[0].Song.Views)
+	require.Equal(t, "2023-01-15", history[0].Song.UploadDate)
+}
+
+func TestBboltStore_Queue(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	store, err := NewBboltStore(dbPath)
+	require.NoError(t, err)
+
+	queue, err := store.LoadQueue()
+	require.NoError(t, err)
+	require.Empty(t, queue, "A new store should have an empty queue")
+
+	songs := []domain.Song{
+		{ID: "song1_id", Title: "Song 1", Artists: []string{"Artist"}},
+		{ID: "song2_id", Title: "Song 2"},
+	}
+	require.NoError(t, store.SaveQueue(songs))
+	require.NoError(t, store.Close())
+
+	store, err = NewBboltStore(dbPath)
+	require.NoError(t, err)
+	defer store.Close()
+
+	queue, err = store.LoadQueue()
+	require.NoError(t, err)
+	require.Equal(t, songs, queue, "The queue should survive reopening the database")
+}
+
+func TestBboltStore_PendingScrobbles(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	store, err := NewBboltStore(dbPath)
+	require.NoError(t, err)
+
+	startedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, id := range []string{"song1_id", "song2_id", "song3_id"} {
+		require.NoError(t, store.AddPendingScrobble(domain.Scrobble{Service: "lastfm", Song: domain.Song{ID: id}, StartedAt: startedAt}))
+	}
+	require.NoError(t, store.Close())
+
+	store, err = NewBboltStore(dbPath)
+	require.NoError(t, err)
+	defer store.Close()
+
+	pending, err := store.GetPendingScrobbles(2)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	require.Equal(t, "song1_id", pending[0].Song.ID, "Scrobbles should be retried in the order they were played")
+	require.Equal(t, startedAt, pending[0].StartedAt)
+
+	require.NoError(t, store.DeletePendingScrobble(pending[0].ID))
+	pending, err = store.GetPendingScrobbles(10)
+	require.NoError(t, err)
+	require.Equal(t, []string{"song2_id", "song3_id"}, []string{pending[0].Song.ID, pending[1].Song.ID})
+}
+
+func TestBboltStore_SearchQueries(t *testing.T) {
+	store, err := NewBboltStore(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	queries, err := store.GetSearchQueries(10)
+	require.NoError(t, err)
+	require.Empty(t, queries)
+
+	require.NoError(t, store.AddSearchQuery("lofi"))
+	require.NoError(t, store.AddSearchQuery("daft punk"))
+	require.NoError(t, store.AddSearchQuery("  "))
+	require.NoError(t, store.AddSearchQuery("Lofi"))
+
+	queries, err = store.GetSearchQueries(10)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Lofi", "daft punk"}, queries, "Repeated queries should move to the front")
+
+	queries, err = store.GetSearchQueries(1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Lofi"}, queries)
+}
@@ -0,0 +1,91 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type TimeoutError struct {
+	Action  string
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s timed out after %s", e.Action, e.Timeout)
+}
+
+func (e *TimeoutError) Unwrap() error { return context.DeadlineExceeded }
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func timedOut(ctx context.Context, action string, timeout time.Duration, err error) error {
+	var timeoutErr *TimeoutError
+	if err == nil || errors.As(err, &timeoutErr) || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return &TimeoutError{Action: action, Timeout: timeout}
+}
+
+var (
+	ErrPrivateVideo  = errors.New("this video is private")
+	ErrAgeRestricted = errors.New("this video is age-restricted, set cookiesPath to play it while signed in")
+	ErrGeoBlocked    = errors.New("this video is not available in your country")
+	ErrUnavailable   = errors.New("this video is unavailable")
+)
+
+var ytdlpErrorPatterns = []struct {
+	err     error
+	phrases []string
+}{
+	{ErrPrivateVideo, []string{"private video", "video is private"}},
+	{ErrAgeRestricted, []string{"confirm your age", "age-restricted", "age restricted", "inappropriate for some users"}},
+	{ErrGeoBlocked, []string{"available in your country", "geo restriction", "geo-restricted", "geo restricted"}},
+	{ErrUnavailable, []string{"video unavailable", "video is unavailable", "has been removed", "no longer available", "does not exist", "is not available"}},
+}
+
+type YTDLPError struct {
+	Kind    error
+	Message string
+}
+
+func (e *YTDLPError) Error() string {
+	if e.Kind != nil {
+		return e.Kind.Error()
+	}
+	return "yt-dlp failed with: " + e.Message
+}
+
+func (e *YTDLPError) Unwrap() error { return e.Kind }
+
+func parseYTDLPError(stderr []byte, err error) error {
+	var message string
+	for _, line := range strings.Split(string(stderr), "\n") {
+		if after, ok := strings.CutPrefix(strings.TrimSpace(line), "ERROR:"); ok {
+			message = strings.TrimSpace(after)
+		}
+	}
+	if message == "" {
+		message = strings.TrimSpace(string(stderr))
+	}
+	if message == "" {
+		return fmt.Errorf("yt-dlp failed: %w", err)
+	}
+
+	lower := strings.ToLower(message)
+	for _, pattern := range ytdlpErrorPatterns {
+		for _, phrase := range pattern.phrases {
+			if strings.Contains(lower, phrase) {
+				return &YTDLPError{Kind: pattern.err, Message: message}
+			}
+		}
+	}
+	return &YTDLPError{Message: message}
+}
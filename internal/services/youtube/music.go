@@ -0,0 +1,330 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"yogo/internal/domain"
+	"yogo/internal/ports"
+
+	"github.com/buger/jsonparser"
+)
+
+const (
+	defaultMusicBaseURL = "https://music.youtube.com"
+	musicClientName     = "WEB_REMIX"
+	musicClientVersion  = "1.20240724.00.00"
+)
+
+var (
+	musicTypeLabels = map[string]bool{"Song": true, "Video": true, "Album": true, "Artist": true, "Playlist": true}
+
+	musicFilterParams = map[string]string{
+		"songs":     "EgWKAQIIAWoKEAkQBRAKEAMQBA==",
+		"videos":    "EgWKAQIQAWoKEAkQChAFEAMQBA==",
+		"albums":    "EgWKAQIYAWoKEAkQChAFEAMQBA==",
+		"artists":   "EgWKAQIgAWoKEAkQChAFEAMQBA==",
+		"playlists": "EgeKAQQoAEABagoQAxAEEAoQCRAF",
+	}
+)
+
+type MusicClient struct {
+	web     *YoutubeClient
+	baseURL string
+	filter  string
+}
+
+func NewMusicClient(cfg domain.Config) ports.YoutubeService {
+	web := newYoutubeClient(cfg.CookiesPath, defaultBaseURL)
+	web.setTimeouts(cfg)
+	return newMusicClient(web, defaultMusicBaseURL, cfg.SearchFilter)
+}
+
+func newMusicClient(web *YoutubeClient, baseURL, filter string) *MusicClient {
+	if _, ok := musicFilterParams[filter]; !ok {
+		filter = "songs"
+	}
+	return &MusicClient{
+		web:     web,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		filter:  filter,
+	}
+}
+
+func (c *MusicClient) Search(ctx context.Context, query string, limit int) ([]domain.Song, error) {
+	if strings.HasPrefix(query, "http") {
+		return c.web.Search(ctx, query, limit)
+	}
+
+	ctx, cancel := withTimeout(ctx, c.web.searchTimeout)
+	defer cancel()
+
+	songs, err := c.search(ctx, query, limit)
+	return songs, timedOut(ctx, "search", c.web.searchTimeout, err)
+}
+
+func (c *MusicClient) search(ctx context.Context, query string, limit int) ([]domain.Song, error) {
+	filter := "songs"
+	if c.filter == "videos" {
+		filter = "videos"
+	}
+
+	var songs []domain.Song
+	page, err := c.searchPage(ctx, query, filter, "")
+	for {
+		if err != nil {
+			return nil, err
+		}
+		songs = append(songs, page.Songs...)
+		if len(songs) >= limit || page.Continuation == "" || len(page.Songs) == 0 {
+			break
+		}
+		page, err = c.searchPage(ctx, query, filter, page.Continuation)
+	}
+
+	if len(songs) > limit {
+		songs = songs[:limit]
+	}
+	return songs, nil
+}
+
+func (c *MusicClient) SearchPage(ctx context.Context, query, continuation string) (domain.SearchPage, error) {
+	return c.SearchPageFiltered(ctx, query, c.filter, continuation)
+}
+
+func (c *MusicClient) SearchFilters() []string {
+	return domain.SearchFilters
+}
+
+func (c *MusicClient) SearchPageFiltered(ctx context.Context, query, filter, continuation string) (domain.SearchPage, error) {
+	if continuation == "" && strings.HasPrefix(query, "http") {
+		return c.web.SearchPage(ctx, query, "")
+	}
+
+	ctx, cancel := withTimeout(ctx, c.web.searchTimeout)
+	defer cancel()
+
+	page, err := c.searchPage(ctx, query, filter, continuation)
+	return page, timedOut(ctx, "search", c.web.searchTimeout, err)
+}
+
+func (c *MusicClient) searchPage(ctx context.Context, query, filter, continuation string) (domain.SearchPage, error) {
+	params, ok := musicFilterParams[filter]
+	if !ok {
+		return domain.SearchPage{}, errors.New("unknown search filter " + filter)
+	}
+
+	payload := map[string]any{
+		"context": map[string]any{
+			"client": map[string]string{
+				"clientName":    musicClientName,
+				"clientVersion": musicClientVersion,
+				"hl":            "en",
+			},
+		},
+	}
+	searchURL := c.baseURL + "/youtubei/v1/search?prettyPrint=false"
+	if continuation != "" {
+		token := url.QueryEscape(continuation)
+		searchURL += "&ctoken=" + token + "&continuation=" + token + "&type=next"
+	} else {
+		payload["query"] = query
+		payload["params"] = params
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return domain.SearchPage{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", searchURL, bytes.NewReader(body))
+	if err != nil {
+		return domain.SearchPage{}, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept-Language", "en")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", c.baseURL)
+
+	resp, err := c.web.do(req)
+	if err != nil {
+		return domain.SearchPage{}, err
+	}
+
+	if continuation != "" {
+		shelf, _, _, err := jsonparser.Get(resp, "continuationContents", "musicShelfContinuation")
+		if err != nil {
+			return domain.SearchPage{}, errors.New("could not find continuation items in JSON")
+		}
+		return parseMusicShelf(shelf), nil
+	}
+
+	sections, _, _, err := jsonparser.Get(resp, "contents", "tabbedSearchResultsRenderer", "tabs", "[0]", "tabRenderer", "content", "sectionListRenderer", "contents")
+	if err != nil {
+		return domain.SearchPage{}, errors.New("could not find music results content block in JSON")
+	}
+
+	page := domain.SearchPage{Songs: []domain.Song{}}
+	jsonparser.ArrayEach(sections, func(section []byte, _ jsonparser.ValueType, _ int, _ error) {
+		shelf, _, _, err := jsonparser.Get(section, "musicShelfRenderer")
+		if err != nil {
+			return
+		}
+		next := parseMusicShelf(shelf)
+		page.Songs = append(page.Songs, next.Songs...)
+		page.Playlists = append(page.Playlists, next.Playlists...)
+		if next.Continuation != "" {
+			page.Continuation = next.Continuation
+		}
+	})
+	return page, nil
+}
+
+func (c *MusicClient) Suggest(query string) ([]string, error) {
+	return c.web.Suggest(query)
+}
+
+func (c *MusicClient) GetSongInfo(ctx context.Context, url string) (domain.Song, error) {
+	return c.web.GetSongInfo(ctx, url)
+}
+
+func (c *MusicClient) GetPlaylist(url string) (domain.Playlist, error) {
+	return c.web.GetPlaylist(url)
+}
+
+func (c *MusicClient) Close() error {
+	return c.web.Close()
+}
+
+func parseMusicShelf(shelf []byte) domain.SearchPage {
+	page := domain.SearchPage{Songs: []domain.Song{}}
+	jsonparser.ArrayEach(shelf, func(item []byte, _ jsonparser.ValueType, _ int, _ error) {
+		renderer, _, _, err := jsonparser.Get(item, "musicResponsiveListItemRenderer")
+		if err != nil {
+			return
+		}
+		song, playlist, ok := parseMusicItem(renderer)
+		if !ok {
+			return
+		}
+		if song.ID != "" {
+			page.Songs = append(page.Songs, song)
+			return
+		}
+		page.Playlists = append(page.Playlists, playlist)
+	}, "contents")
+	page.Continuation, _ = jsonparser.GetString(shelf, "continuations", "[0]", "nextContinuationData", "continuation")
+	return page
+}
+
+type musicRun struct {
+	text     string
+	browseID string
+	pageType string
+}
+
+func musicColumnRuns(renderer []byte, column int) []musicRun {
+	var runs []musicRun
+	jsonparser.ArrayEach(renderer, func(value []byte, _ jsonparser.ValueType, _ int, _ error) {
+		text, _ := jsonparser.GetString(value, "text")
+		browseID, _ := jsonparser.GetString(value, "navigationEndpoint", "browseEndpoint", "browseId")
+		pageType, _ := jsonparser.GetString(value, "navigationEndpoint", "browseEndpoint", "browseEndpointContextSupportedConfigs", "browseEndpointContextMusicConfig", "pageType")
+		runs = append(runs, musicRun{text: text, browseID: browseID, pageType: pageType})
+	}, "flexColumns", "["+strconv.Itoa(column)+"]", "musicResponsiveListItemFlexColumnRenderer", "text", "runs")
+	return runs
+}
+
+func parseMusicItem(renderer []byte) (domain.Song, domain.Playlist, bool) {
+	titleRuns := musicColumnRuns(renderer, 0)
+	if len(titleRuns) == 0 {
+		return domain.Song{}, domain.Playlist{}, false
+	}
+	title := titleRuns[0].text
+	subtitle := musicColumnRuns(renderer, 1)
+
+	var thumbnail string
+	jsonparser.ArrayEach(renderer, func(value []byte, _ jsonparser.ValueType, _ int, _ error) {
+		if thumbnailURL, err := jsonparser.GetString(value, "url"); err == nil {
+			thumbnail = thumbnailURL
+		}
+	}, "thumbnail", "musicThumbnailRenderer", "thumbnail", "thumbnails")
+
+	var artists []string
+	var channelID, album string
+	duration := 0
+	var details []string
+	for _, run := range subtitle {
+		switch run.pageType {
+		case "MUSIC_PAGE_TYPE_ARTIST", "MUSIC_PAGE_TYPE_USER_CHANNEL":
+			artists = append(artists, run.text)
+			if channelID == "" {
+				channelID = run.browseID
+			}
+		case "MUSIC_PAGE_TYPE_ALBUM":
+			album = run.text
+		default:
+			text := strings.TrimSpace(run.text)
+			if text == "" || text == "•" || text == "&" || text == "," {
+				continue
+			}
+			if strings.Contains(text, ":") {
+				duration = parseClock(text)
+				continue
+			}
+			if len(details) == 0 && musicTypeLabels[text] {
+				continue
+			}
+			details = append(details, text)
+		}
+	}
+
+	videoID, _ := jsonparser.GetString(renderer, "playlistItemData", "videoId")
+	if videoID == "" {
+		videoID, _ = jsonparser.GetString(renderer, "flexColumns", "[0]", "musicResponsiveListItemFlexColumnRenderer", "text", "runs", "[0]", "navigationEndpoint", "watchEndpoint", "videoId")
+	}
+	if videoID != "" {
+		song := domain.Song{
+			ID:        videoID,
+			Title:     title,
+			Artists:   artists,
+			Album:     album,
+			Duration:  duration,
+			Thumbnail: thumbnail,
+			ChannelID: channelID,
+		}
+		if channelID != "" {
+			song.ChannelURL = defaultMusicBaseURL + "/channel/" + channelID
+		}
+		return song, domain.Playlist{}, true
+	}
+
+	browseID, _ := jsonparser.GetString(renderer, "navigationEndpoint", "browseEndpoint", "browseId")
+	pageType, _ := jsonparser.GetString(renderer, "navigationEndpoint", "browseEndpoint", "browseEndpointContextSupportedConfigs", "browseEndpointContextMusicConfig", "pageType")
+	if browseID == "" {
+		return domain.Song{}, domain.Playlist{}, false
+	}
+
+	playlist := domain.Playlist{Title: title, Author: strings.Join(append(artists, details...), " · ")}
+	switch pageType {
+	case "MUSIC_PAGE_TYPE_ALBUM", "MUSIC_PAGE_TYPE_AUDIOBOOK":
+		playlist.Kind = domain.PlaylistAlbum
+		playlist.Author = strings.Join(artists, ", ")
+		playlist.ID, _ = jsonparser.GetString(renderer, "overlay", "musicItemThumbnailOverlayRenderer", "content", "musicPlayButtonRenderer", "playNavigationEndpoint", "watchPlaylistEndpoint", "playlistId")
+		if playlist.ID == "" {
+			playlist.ID = defaultMusicBaseURL + "/browse/" + browseID
+		}
+	case "MUSIC_PAGE_TYPE_ARTIST", "MUSIC_PAGE_TYPE_USER_CHANNEL":
+		playlist.Kind = domain.PlaylistArtist
+		playlist.ID = defaultBaseURL + "/channel/" + browseID + "/videos"
+	case "MUSIC_PAGE_TYPE_PLAYLIST":
+		playlist.ID = strings.TrimPrefix(browseID, "VL")
+	default:
+		return domain.Song{}, domain.Playlist{}, false
+	}
+	return domain.Song{}, playlist, true
+}
@@ -0,0 +1,160 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func musicRunJSON(text, browseID, pageType string) map[string]any {
+	run := map[string]any{"text": text}
+	if browseID != "" {
+		run["navigationEndpoint"] = map[string]any{
+			"browseEndpoint": map[string]any{
+				"browseId": browseID,
+				"browseEndpointContextSupportedConfigs": map[string]any{
+					"browseEndpointContextMusicConfig": map[string]any{"pageType": pageType},
+				},
+			},
+		}
+	}
+	return run
+}
+
+func musicItemJSON(videoID, title string, subtitle ...map[string]any) map[string]any {
+	titleRun := map[string]any{"text": title}
+	renderer := map[string]any{
+		"flexColumns": []any{
+			map[string]any{"musicResponsiveListItemFlexColumnRenderer": map[string]any{"text": map[string]any{"runs": []any{titleRun}}}},
+			map[string]any{"musicResponsiveListItemFlexColumnRenderer": map[string]any{"text": map[string]any{"runs": subtitle}}},
+		},
+		"thumbnail": map[string]any{"musicThumbnailRenderer": map[string]any{"thumbnail": map[string]any{"thumbnails": []any{
+			map[string]any{"url": "https://lh3.googleusercontent.com/" + title},
+		}}}},
+	}
+	if videoID != "" {
+		renderer["playlistItemData"] = map[string]any{"videoId": videoID}
+	}
+	return map[string]any{"musicResponsiveListItemRenderer": renderer}
+}
+
+func newMusicServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	song := musicItemJSON("s1", "Harder, Better",
+		musicRunJSON("Daft Punk", "UCdp", "MUSIC_PAGE_TYPE_ARTIST"),
+		musicRunJSON(" & ", "", ""),
+		musicRunJSON("Pharrell", "UCph", "MUSIC_PAGE_TYPE_ARTIST"),
+		musicRunJSON(" • ", "", ""),
+		musicRunJSON("Discovery", "MPREb_disc", "MUSIC_PAGE_TYPE_ALBUM"),
+		musicRunJSON(" • ", "", ""),
+		musicRunJSON("3:45", "", ""),
+	)
+	album := musicItemJSON("", "Discovery",
+		musicRunJSON("Album", "", ""),
+		musicRunJSON(" • ", "", ""),
+		musicRunJSON("Daft Punk", "UCdp", "MUSIC_PAGE_TYPE_ARTIST"),
+		musicRunJSON(" • ", "", ""),
+		musicRunJSON("2001", "", ""),
+	)
+	album["musicResponsiveListItemRenderer"].(map[string]any)["navigationEndpoint"] = musicRunJSON("", "MPREb_disc", "MUSIC_PAGE_TYPE_ALBUM")["navigationEndpoint"]
+	album["musicResponsiveListItemRenderer"].(map[string]any)["overlay"] = map[string]any{
+		"musicItemThumbnailOverlayRenderer": map[string]any{"content": map[string]any{"musicPlayButtonRenderer": map[string]any{
+			"playNavigationEndpoint": map[string]any{"watchPlaylistEndpoint": map[string]any{"playlistId": "OLAK5uy_disc"}},
+		}}},
+	}
+	next := musicItemJSON("s2", "One More Time",
+		musicRunJSON("Daft Punk", "UCdp", "MUSIC_PAGE_TYPE_ARTIST"),
+		musicRunJSON(" • ", "", ""),
+		musicRunJSON("5:20", "", ""),
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/youtubei/v1/search", r.URL.Path)
+
+		var req struct {
+			Context struct {
+				Client struct {
+					ClientName string `json:"clientName"`
+				} `json:"client"`
+			} `json:"context"`
+			Query  string `json:"query"`
+			Params string `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "WEB_REMIX", req.Context.Client.ClientName)
+
+		if token := r.URL.Query().Get("continuation"); token != "" {
+			require.Equal(t, "next-page", token)
+			json.NewEncoder(w).Encode(map[string]any{
+				"continuationContents": map[string]any{"musicShelfContinuation": map[string]any{"contents": []any{next}}},
+			})
+			return
+		}
+
+		require.Equal(t, "daft punk", req.Query)
+		items := []any{song}
+		if req.Params == musicFilterParams["albums"] {
+			items = []any{album}
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"contents": map[string]any{"tabbedSearchResultsRenderer": map[string]any{"tabs": []any{
+				map[string]any{"tabRenderer": map[string]any{"content": map[string]any{"sectionListRenderer": map[string]any{"contents": []any{
+					map[string]any{"musicShelfRenderer": map[string]any{
+						"contents":      items,
+						"continuations": []any{map[string]any{"nextContinuationData": map[string]any{"continuation": "next-page"}}},
+					}},
+				}}}}},
+			}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestMusicSearchSongs(t *testing.T) {
+	server := newMusicServer(t)
+	client := newMusicClient(newYoutubeClient("", server.URL), server.URL, "songs")
+
+	page, err := client.SearchPage(context.Background(), "daft punk", "")
+	require.NoError(t, err)
+	require.Len(t, page.Songs, 1)
+	song := page.Songs[0]
+	require.Equal(t, "s1", song.ID)
+	require.Equal(t, "Harder, Better", song.Title)
+	require.Equal(t, []string{"Daft Punk", "Pharrell"}, song.Artists)
+	require.Equal(t, "Discovery", song.Album)
+	require.Equal(t, 225, song.Duration)
+	require.Equal(t, "UCdp", song.ChannelID)
+	require.Equal(t, "next-page", page.Continuation)
+
+	page, err = client.SearchPage(context.Background(), "daft punk", page.Continuation)
+	require.NoError(t, err)
+	require.Equal(t, "s2", page.Songs[0].ID)
+	require.Empty(t, page.Continuation)
+
+	songs, err := client.Search(context.Background(), "daft punk", 5)
+	require.NoError(t, err)
+	require.Len(t, songs, 2)
+}
+
+func TestMusicSearchAlbums(t *testing.T) {
+	server := newMusicServer(t)
+	client := newMusicClient(newYoutubeClient("", server.URL), server.URL, "songs")
+
+	page, err := client.SearchPageFiltered(context.Background(), "daft punk", "albums", "")
+	require.NoError(t, err)
+	require.Empty(t, page.Songs)
+	require.Len(t, page.Playlists, 1)
+	require.Equal(t, "OLAK5uy_disc", page.Playlists[0].ID)
+	require.Equal(t, "Discovery", page.Playlists[0].Title)
+	require.Equal(t, "album", page.Playlists[0].Kind)
+	require.Equal(t, "Daft Punk", page.Playlists[0].Author)
+
+	_, err = client.SearchPageFiltered(context.Background(), "daft punk", "podcasts", "")
+	require.Error(t, err)
+}
@@ -0,0 +1,89 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	maxYTDLPProcesses = 3
+	ytdlpWaitDelay    = time.Second
+)
+
+var errSupervisorClosed = errors.New("yt-dlp supervisor is shut down")
+
+type supervisor struct {
+	slots    chan struct{}
+	shutdown context.Context
+	stop     context.CancelFunc
+
+	mu      sync.Mutex
+	closed  bool
+	running sync.WaitGroup
+}
+
+func newSupervisor(workers int) *supervisor {
+	shutdown, stop := context.WithCancel(context.Background())
+	return &supervisor{
+		slots:    make(chan struct{}, workers),
+		shutdown: shutdown,
+		stop:     stop,
+	}
+}
+
+func (s *supervisor) run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	select {
+	case s.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	case <-s.shutdown.Done():
+		return nil, nil, errSupervisorClosed
+	}
+	defer func() { <-s.slots }()
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil, nil, errSupervisorClosed
+	}
+	s.running.Add(1)
+	s.mu.Unlock()
+	defer s.running.Done()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer context.AfterFunc(s.shutdown, cancel)()
+
+	cmd := execCommand(ctx, name, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = ytdlpWaitDelay
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if s.shutdown.Err() != nil {
+		return nil, stderr.Bytes(), errSupervisorClosed
+	}
+	if ctx.Err() != nil {
+		return nil, stderr.Bytes(), ctx.Err()
+	}
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+func (s *supervisor) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.stop()
+	s.running.Wait()
+	return nil
+}
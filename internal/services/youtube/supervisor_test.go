@@ -0,0 +1,93 @@
+package youtube
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func fakeYTDLP(t *testing.T, script string) {
+	t.Helper()
+	execCommand = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "sh", "-c", script)
+	}
+	t.Cleanup(func() { execCommand = exec.CommandContext })
+}
+
+func processAlive(pid int) bool {
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return false
+	}
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) > 0 && fields[0] != "Z"
+}
+
+func TestSupervisorLimitsConcurrentProcesses(t *testing.T) {
+	fakeYTDLP(t, "sleep 0.2")
+	s := newSupervisor(2)
+	t.Cleanup(func() { s.Close() })
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := s.run(context.Background(), "yt-dlp")
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	require.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+}
+
+func TestSupervisorCancelsOnDeadline(t *testing.T) {
+	fakeYTDLP(t, "sleep 30")
+	s := newSupervisor(1)
+	t.Cleanup(func() { s.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, _, err := s.run(ctx, "yt-dlp")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestSupervisorCloseKillsProcessGroup(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	fakeYTDLP(t, "sleep 30 & echo $! > "+pidFile+"; wait")
+	s := newSupervisor(1)
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := s.run(context.Background(), "yt-dlp")
+		done <- err
+	}()
+
+	var pid int
+	require.Eventually(t, func() bool {
+		data, err := os.ReadFile(pidFile)
+		if err != nil {
+			return false
+		}
+		pid, err = strconv.Atoi(strings.TrimSpace(string(data)))
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, s.Close())
+	require.ErrorIs(t, <-done, errSupervisorClosed)
+	require.Eventually(t, func() bool { return !processAlive(pid) }, 5*time.Second, 10*time.Millisecond)
+
+	_, _, err := s.run(context.Background(), "yt-dlp")
+	require.ErrorIs(t, err, errSupervisorClosed)
+}
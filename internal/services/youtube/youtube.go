@@ -2,6 +2,7 @@ package youtube
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,7 +11,10 @@ import (
 	"net/url"
 	"os/exec"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 	"yogo/internal/domain"
 	"yogo/internal/logger"
 	"yogo/internal/ports"
@@ -19,111 +23,387 @@ import (
 )
 
 var (
-	execCommand      = exec.Command
-	initialDataRegex = regexp.MustCompile(`(?:var\s*ytInitialData|window\["ytInitialData"\])\s*=\s*(.*?);</script>`)
+	execCommand        = exec.CommandContext
+	initialDataRegex   = regexp.MustCompile(`(?:var\s*ytInitialData|window\["ytInitialData"\])\s*=\s*(.*?);</script>`)
+	clientVersionRegex = regexp.MustCompile(`"INNERTUBE_CLIENT_VERSION":"([^"]+)"`)
+)
+
+const (
+	defaultBaseURL       = "https://www.youtube.com"
+	defaultSuggestURL    = "https://suggestqueries-clients6.youtube.com/complete/search"
+	defaultClientVersion = "2.20240726.00.00"
+	channelSongLimit     = 50
+	userAgent            = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"
 )
 
 type YoutubeClient struct {
-	cookiesPath string
-	httpClient  *http.Client
+	cookiesPath     string
+	baseURL         string
+	suggestURL      string
+	httpClient      *http.Client
+	ytdlp           *supervisor
+	searchTimeout   time.Duration
+	songInfoTimeout time.Duration
+
+	mu            sync.Mutex
+	clientVersion string
+}
+
+func NewYoutubeClient(cfg domain.Config) ports.YoutubeService {
+	client := newYoutubeClient(cfg.CookiesPath, defaultBaseURL)
+	client.setTimeouts(cfg)
+	return client
 }
 
-func NewYoutubeClient(cookiesPath string) ports.YoutubeService {
+func NewYoutubeService(cfg domain.Config) ports.YoutubeService {
+	if cfg.SearchBackend == domain.SearchBackendMusic {
+		return NewMusicClient(cfg)
+	}
+	return NewYoutubeClient(cfg)
+}
+
+func newYoutubeClient(cookiesPath, baseURL string) *YoutubeClient {
 	return &YoutubeClient{
-		cookiesPath: cookiesPath,
-		httpClient:  &http.Client{},
+		cookiesPath:   cookiesPath,
+		baseURL:       strings.TrimSuffix(baseURL, "/"),
+		suggestURL:    defaultSuggestURL,
+		httpClient:    &http.Client{},
+		ytdlp:         newSupervisor(maxYTDLPProcesses),
+		clientVersion: defaultClientVersion,
 	}
 }
 
-func (c *YoutubeClient) Search(query string, limit int) ([]domain.Song, error) {
+func (c *YoutubeClient) setTimeouts(cfg domain.Config) {
+	c.searchTimeout = time.Duration(cfg.SearchTimeout) * time.Second
+	c.songInfoTimeout = time.Duration(cfg.SongInfoTimeout) * time.Second
+	c.httpClient.Timeout = c.searchTimeout
+}
+
+func (c *YoutubeClient) Search(ctx context.Context, query string, limit int) ([]domain.Song, error) {
+	ctx, cancel := withTimeout(ctx, c.searchTimeout)
+	defer cancel()
+
+	songs, err := c.search(ctx, query, limit)
+	return songs, timedOut(ctx, "search", c.searchTimeout, err)
+}
+
+func (c *YoutubeClient) search(ctx context.Context, query string, limit int) ([]domain.Song, error) {
+	if isPlaylistURL(query) {
+		playlist, err := c.getPlaylist(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		if len(playlist.Songs) > limit {
+			return playlist.Songs[:limit], nil
+		}
+		return playlist.Songs, nil
+	}
 	if strings.HasPrefix(query, "http") {
-		return c.getSongInfoFromURL(query)
+		return c.getSongInfoFromURL(ctx, query)
+	}
+
+	var songs []domain.Song
+	page, err := c.scrapeSearchResults(ctx, query)
+	for {
+		if err != nil {
+			return nil, err
+		}
+		songs = append(songs, page.Songs...)
+		if len(songs) >= limit || page.Continuation == "" || len(page.Songs) == 0 {
+			break
+		}
+		page, err = c.fetchContinuation(ctx, page.Continuation)
+	}
+
+	if len(songs) > limit {
+		songs = songs[:limit]
 	}
-	return c.scrapeSearchResults(query, limit)
+	return songs, nil
 }
 
-func (c *YoutubeClient) scrapeSearchResults(query string, limit int) ([]domain.Song, error) {
-	searchURL := "https://www.youtube.com/results?search_query=" + url.QueryEscape(query)
+func (c *YoutubeClient) SearchPage(ctx context.Context, query, continuation string) (domain.SearchPage, error) {
+	ctx, cancel := withTimeout(ctx, c.searchTimeout)
+	defer cancel()
+
+	page, err := c.searchPage(ctx, query, continuation)
+	return page, timedOut(ctx, "search", c.searchTimeout, err)
+}
+
+func (c *YoutubeClient) searchPage(ctx context.Context, query, continuation string) (domain.SearchPage, error) {
+	if continuation != "" {
+		return c.fetchContinuation(ctx, continuation)
+	}
+	if isPlaylistURL(query) {
+		playlist, err := c.getPlaylist(ctx, query)
+		if err != nil {
+			return domain.SearchPage{}, err
+		}
+		return domain.SearchPage{Playlists: []domain.Playlist{playlist}}, nil
+	}
+	if strings.HasPrefix(query, "http") {
+		songs, err := c.getSongInfoFromURL(ctx, query)
+		return domain.SearchPage{Songs: songs}, err
+	}
+	return c.scrapeSearchResults(ctx, query)
+}
 
-	req, err := http.NewRequest("GET", searchURL, nil)
+func (c *YoutubeClient) Suggest(query string) ([]string, error) {
+	params := url.Values{"client": {"firefox"}, "ds": {"yt"}, "hl": {"en"}, "q": {query}}
+	req, err := http.NewRequest("GET", c.suggestURL+"?"+params.Encode(), nil)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
-	req.Header.Set("Accept-Language", "en")
+	req.Header.Set("User-Agent", userAgent)
 
-	resp, err := c.httpClient.Do(req)
+	body, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("youtube returned non-200 status code: %d", resp.StatusCode)
+	suggestions := []string{}
+	_, err = jsonparser.ArrayEach(body, func(value []byte, dataType jsonparser.ValueType, _ int, _ error) {
+		if dataType == jsonparser.String {
+			if suggestion, err := jsonparser.ParseString(value); err == nil {
+				suggestions = append(suggestions, suggestion)
+			}
+		}
+	}, "[1]")
+	if err != nil {
+		return nil, fmt.Errorf("could not parse suggestions: %w", err)
+	}
+	return suggestions, nil
+}
+
+func (c *YoutubeClient) scrapeSearchResults(ctx context.Context, query string) (domain.SearchPage, error) {
+	searchURL := c.baseURL + "/results?search_query=" + url.QueryEscape(query)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
+	if err != nil {
+		return domain.SearchPage{}, err
 	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept-Language", "en")
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := c.do(req)
 	if err != nil {
-		return nil, err
+		return domain.SearchPage{}, err
+	}
+
+	if matches := clientVersionRegex.FindSubmatch(body); len(matches) == 2 {
+		c.mu.Lock()
+		c.clientVersion = string(matches[1])
+		c.mu.Unlock()
 	}
 
 	matches := initialDataRegex.FindSubmatch(body)
 	if len(matches) < 2 {
-		return nil, errors.New("could not find ytInitialData script block")
+		return domain.SearchPage{}, errors.New("could not find ytInitialData script block")
 	}
 	jsonData := matches[1]
 
 	contentRoot, _, _, err := jsonparser.Get(jsonData, "contents", "twoColumnSearchResultsRenderer", "primaryContents", "sectionListRenderer", "contents")
 	if err != nil {
-		return nil, fmt.Errorf("could not parse initial JSON structure: %w", err)
+		return domain.SearchPage{}, fmt.Errorf("could not parse initial JSON structure: %w", err)
 	}
 
-	var resultsContents []byte
-	jsonparser.ArrayEach(contentRoot, func(contentBlock []byte, dataType jsonparser.ValueType, offset int, _ error) {
-		contents, _, _, err := jsonparser.Get(contentBlock, "itemSectionRenderer", "contents")
-		if err == nil {
-			resultsContents = contents
-		}
+	page := parseSectionContents(contentRoot)
+	if page.Songs == nil && page.Continuation == "" {
+		return domain.SearchPage{}, errors.New("could not find video results content block in JSON")
+	}
+	return page, nil
+}
+
+func (c *YoutubeClient) fetchContinuation(ctx context.Context, token string) (domain.SearchPage, error) {
+	c.mu.Lock()
+	clientVersion := c.clientVersion
+	c.mu.Unlock()
+
+	payload, err := json.Marshal(map[string]any{
+		"context": map[string]any{
+			"client": map[string]string{
+				"clientName":    "WEB",
+				"clientVersion": clientVersion,
+				"hl":            "en",
+			},
+		},
+		"continuation": token,
 	})
+	if err != nil {
+		return domain.SearchPage{}, err
+	}
 
-	if resultsContents == nil {
-		return nil, errors.New("could not find video results content block in JSON")
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/youtubei/v1/search?prettyPrint=false", bytes.NewReader(payload))
+	if err != nil {
+		return domain.SearchPage{}, err
 	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept-Language", "en")
+	req.Header.Set("Content-Type", "application/json")
 
-	var songs []domain.Song
-	count := 0
-	jsonparser.ArrayEach(resultsContents, func(value []byte, dataType jsonparser.ValueType, offset int, _ error) {
-		if count >= limit {
-			return
-		}
-		videoID, err := jsonparser.GetString(value, "videoRenderer", "videoId")
+	body, err := c.do(req)
+	if err != nil {
+		return domain.SearchPage{}, err
+	}
+
+	var page domain.SearchPage
+	found := false
+	jsonparser.ArrayEach(body, func(command []byte, _ jsonparser.ValueType, _ int, _ error) {
+		items, _, _, err := jsonparser.Get(command, "appendContinuationItemsAction", "continuationItems")
 		if err != nil {
 			return
 		}
+		found = true
+		next := parseSectionContents(items)
+		page.Songs = append(page.Songs, next.Songs...)
+		page.Playlists = append(page.Playlists, next.Playlists...)
+		if next.Continuation != "" {
+			page.Continuation = next.Continuation
+		}
+	}, "onResponseReceivedCommands")
 
-		title, _ := jsonparser.GetString(value, "videoRenderer", "title", "runs", "[0]", "text")
-		uploader, _ := jsonparser.GetString(value, "videoRenderer", "ownerText", "runs", "[0]", "text")
+	if !found {
+		return domain.SearchPage{}, errors.New("could not find continuation items in JSON")
+	}
+	return page, nil
+}
 
-		songs = append(songs, domain.Song{
-			ID:      videoID,
-			Title:   title,
-			Artists: []string{uploader},
-		})
-		count++
+func (c *YoutubeClient) do(req *http.Request) ([]byte, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("youtube returned non-200 status code: %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+func parseSectionContents(contents []byte) domain.SearchPage {
+	var page domain.SearchPage
+	jsonparser.ArrayEach(contents, func(contentBlock []byte, _ jsonparser.ValueType, _ int, _ error) {
+		if items, _, _, err := jsonparser.Get(contentBlock, "itemSectionRenderer", "contents"); err == nil {
+			songs, playlists := parseItemSection(items)
+			page.Songs = append(page.Songs, songs...)
+			page.Playlists = append(page.Playlists, playlists...)
+			return
+		}
+		if token, err := jsonparser.GetString(contentBlock, "continuationItemRenderer", "continuationEndpoint", "continuationCommand", "token"); err == nil {
+			page.Continuation = token
+		}
 	})
+	return page
+}
 
-	return songs, nil
+func parseItemSection(items []byte) ([]domain.Song, []domain.Playlist) {
+	songs := []domain.Song{}
+	var playlists []domain.Playlist
+	jsonparser.ArrayEach(items, func(value []byte, _ jsonparser.ValueType, _ int, _ error) {
+		if videoID, err := jsonparser.GetString(value, "videoRenderer", "videoId"); err == nil {
+			songs = append(songs, parseVideoRenderer(videoID, value))
+			return
+		}
+		if playlistID, err := jsonparser.GetString(value, "playlistRenderer", "playlistId"); err == nil {
+			playlists = append(playlists, parsePlaylistRenderer(playlistID, value))
+		}
+	})
+	return songs, playlists
+}
+
+func parsePlaylistRenderer(playlistID string, value []byte) domain.Playlist {
+	renderer, _, _, _ := jsonparser.Get(value, "playlistRenderer")
+
+	title, _ := jsonparser.GetString(renderer, "title", "simpleText")
+	author, _ := jsonparser.GetString(renderer, "shortBylineText", "runs", "[0]", "text")
+	count, _ := jsonparser.GetString(renderer, "videoCount")
+
+	return domain.Playlist{
+		ID:     playlistID,
+		Title:  title,
+		Author: author,
+		Count:  int(parseCount(count)),
+	}
+}
+
+func parseVideoRenderer(videoID string, value []byte) domain.Song {
+	renderer, _, _, _ := jsonparser.Get(value, "videoRenderer")
+
+	title, _ := jsonparser.GetString(renderer, "title", "runs", "[0]", "text")
+	uploader, _ := jsonparser.GetString(renderer, "ownerText", "runs", "[0]", "text")
+	length, _ := jsonparser.GetString(renderer, "lengthText", "simpleText")
+	views, _ := jsonparser.GetString(renderer, "viewCountText", "simpleText")
+	channelID, _ := jsonparser.GetString(renderer, "ownerText", "runs", "[0]", "navigationEndpoint", "browseEndpoint", "browseId")
+	channelPath, _ := jsonparser.GetString(renderer, "ownerText", "runs", "[0]", "navigationEndpoint", "browseEndpoint", "canonicalBaseUrl")
+
+	var thumbnail string
+	jsonparser.ArrayEach(renderer, func(value []byte, _ jsonparser.ValueType, _ int, _ error) {
+		if thumbnailURL, err := jsonparser.GetString(value, "url"); err == nil {
+			thumbnail = thumbnailURL
+		}
+	}, "thumbnail", "thumbnails")
+
+	if channelPath == "" && channelID != "" {
+		channelPath = "/channel/" + channelID
+	}
+	var channelURL string
+	if channelPath != "" {
+		channelURL = defaultBaseURL + channelPath
+	}
+
+	return domain.Song{
+		ID:         videoID,
+		Title:      title,
+		Artists:    []string{uploader},
+		Duration:   parseClock(length),
+		Thumbnail:  thumbnail,
+		ChannelID:  channelID,
+		ChannelURL: channelURL,
+		Views:      parseCount(views),
+	}
+}
+
+func parseClock(text string) int {
+	seconds := 0
+	for _, part := range strings.Split(text, ":") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return 0
+		}
+		seconds = seconds*60 + n
+	}
+	return seconds
+}
+
+func parseCount(text string) int64 {
+	var count int64
+	for _, r := range text {
+		if r >= '0' && r <= '9' {
+			count = count*10 + int64(r-'0')
+		}
+	}
+	return count
 }
 
 type ytdlpSingleEntry struct {
-	ID       string `json:"id"`
-	Title    string `json:"title"`
-	Uploader string `json:"uploader"`
-	Channel  string `json:"channel"`
+	ID         string  `json:"id"`
+	Title      string  `json:"title"`
+	Uploader   string  `json:"uploader"`
+	Channel    string  `json:"channel"`
+	ChannelID  string  `json:"channel_id"`
+	ChannelURL string  `json:"channel_url"`
+	Duration   float64 `json:"duration"`
+	Thumbnail  string  `json:"thumbnail"`
+	ViewCount  int64   `json:"view_count"`
+	UploadDate string  `json:"upload_date"`
+	Thumbnails []struct {
+		URL string `json:"url"`
+	} `json:"thumbnails"`
 }
 
-func (c *YoutubeClient) getSongInfoFromURL(url string) ([]domain.Song, error) {
-	output, err := c.executeYTDLP("--dump-single-json", "--", url)
+func (c *YoutubeClient) getSongInfoFromURL(ctx context.Context, url string) ([]domain.Song, error) {
+	output, err := c.executeYTDLP(ctx, "--dump-single-json", "--", url)
 	if err != nil {
 		return nil, err
 	}
@@ -133,22 +413,124 @@ func (c *YoutubeClient) getSongInfoFromURL(url string) ([]domain.Song, error) {
 		return nil, err
 	}
 
-	artist := entry.Uploader
+	return []domain.Song{entry.toSong()}, nil
+}
+
+func (e ytdlpSingleEntry) toSong() domain.Song {
+	artist := e.Uploader
 	if artist == "" {
-		artist = entry.Channel
+		artist = e.Channel
+	}
+	thumbnail := e.Thumbnail
+	if thumbnail == "" && len(e.Thumbnails) > 0 {
+		thumbnail = e.Thumbnails[len(e.Thumbnails)-1].URL
 	}
-	song := domain.Song{
-		ID:      entry.ID,
-		Title:   entry.Title,
-		Artists: []string{artist},
+	return domain.Song{
+		ID:         e.ID,
+		Title:      e.Title,
+		Artists:    []string{artist},
+		Duration:   int(e.Duration),
+		Thumbnail:  thumbnail,
+		ChannelID:  e.ChannelID,
+		ChannelURL: e.ChannelURL,
+		Views:      e.ViewCount,
+		UploadDate: formatUploadDate(e.UploadDate),
 	}
-	return []domain.Song{song}, nil
 }
 
-func (c *YoutubeClient) GetSongInfo(url string) (domain.Song, error) {
-	songs, err := c.getSongInfoFromURL(url)
+type ytdlpPlaylist struct {
+	ID            string             `json:"id"`
+	Title         string             `json:"title"`
+	Uploader      string             `json:"uploader"`
+	Channel       string             `json:"channel"`
+	PlaylistCount int                `json:"playlist_count"`
+	Entries       []ytdlpSingleEntry `json:"entries"`
+}
+
+func (c *YoutubeClient) GetPlaylist(playlistURL string) (domain.Playlist, error) {
+	ctx, cancel := withTimeout(context.Background(), c.songInfoTimeout)
+	defer cancel()
+
+	playlist, err := c.getPlaylist(ctx, playlistURL)
+	return playlist, timedOut(ctx, "loading the playlist", c.songInfoTimeout, err)
+}
+
+func (c *YoutubeClient) getPlaylist(ctx context.Context, playlistURL string) (domain.Playlist, error) {
+	if !strings.HasPrefix(playlistURL, "http") {
+		playlistURL = defaultBaseURL + "/playlist?list=" + url.QueryEscape(playlistURL)
+	}
+
+	args := []string{"--flat-playlist", "-J"}
+	if isChannelURL(playlistURL) {
+		args = append(args, "--playlist-end", strconv.Itoa(channelSongLimit))
+	}
+	output, err := c.executeYTDLP(ctx, append(args, "--", playlistURL)...)
 	if err != nil {
-		return domain.Song{}, err
+		return domain.Playlist{}, err
+	}
+
+	var entry ytdlpPlaylist
+	if err := json.Unmarshal(output, &entry); err != nil {
+		return domain.Playlist{}, err
+	}
+
+	author := entry.Uploader
+	if author == "" {
+		author = entry.Channel
+	}
+	playlist := domain.Playlist{
+		ID:     entry.ID,
+		Title:  entry.Title,
+		Author: author,
+		Count:  entry.PlaylistCount,
+		Songs:  []domain.Song{},
+	}
+	for _, e := range entry.Entries {
+		if e.ID == "" || e.Title == "[Private video]" || e.Title == "[Deleted video]" {
+			continue
+		}
+		playlist.Songs = append(playlist.Songs, e.toSong())
+	}
+	return playlist, nil
+}
+
+func isPlaylistURL(query string) bool {
+	u, err := url.Parse(query)
+	if err != nil || !strings.HasPrefix(u.Scheme, "http") {
+		return false
+	}
+	values := u.Query()
+	return values.Get("list") != "" && (u.Path == "/playlist" || values.Get("v") == "")
+}
+
+func isChannelURL(playlistURL string) bool {
+	u, err := url.Parse(playlistURL)
+	if err != nil {
+		return false
+	}
+	for _, prefix := range []string{"/channel/", "/@", "/c/", "/user/"} {
+		if strings.HasPrefix(u.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatUploadDate(date string) string {
+	t, err := time.Parse("20060102", date)
+	if err != nil {
+		return date
+	}
+	return t.Format(time.DateOnly)
+}
+
+func (c *YoutubeClient) GetSongInfo(ctx context.Context, url string) (domain.Song, error) {
+	ctx, cancel := withTimeout(ctx, c.songInfoTimeout)
+	defer cancel()
+
+	songs, err := c.getSongInfoFromURL(ctx, url)
+	if err != nil {
+		return domain.Song{}, timedOut(ctx, "looking up the song", c.songInfoTimeout, err)
 	}
 	if len(songs) == 0 {
 		return domain.Song{}, errors.New("no song info found for url")
@@ -156,27 +538,28 @@ func (c *YoutubeClient) GetSongInfo(url string) (domain.Song, error) {
 	return songs[0], nil
 }
 
-func (c *YoutubeClient) executeYTDLP(args ...string) ([]byte, error) {
+func (c *YoutubeClient) executeYTDLP(ctx context.Context, args ...string) ([]byte, error) {
 	if c.cookiesPath != "" {
 		fullArgs := append([]string{"--cookies", c.cookiesPath}, args...)
 		args = fullArgs
 	}
 
-	cmd := execCommand("yt-dlp", args...)
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	stdout, stderr, err := c.ytdlp.run(ctx, "yt-dlp", args...)
 
-	err := cmd.Run()
-
-	if stderr.Len() > 0 {
-		logger.Log.Warn().Strs("args", args).Str("stderr", stderr.String()).Msg("yt-dlp stderr output")
+	if len(stderr) > 0 {
+		logger.Log.Warn().Strs("args", args).Str("stderr", string(stderr)).Msg("yt-dlp stderr output")
 	}
 
 	if err != nil {
-		return nil, fmt.Errorf("yt-dlp failed with: %s", strings.TrimSpace(stderr.String()))
+		if ctx.Err() != nil || errors.Is(err, errSupervisorClosed) {
+			return nil, err
+		}
+		return nil, parseYTDLPError(stderr, err)
 	}
 
-	return stdout.Bytes(), nil
+	return stdout, nil
+}
+
+func (c *YoutubeClient) Close() error {
+	return c.ytdlp.Close()
 }
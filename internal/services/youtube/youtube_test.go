@@ -0,0 +1,300 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func videoItems(ids ...string) []any {
+	items := make([]any, len(ids))
+	for i, id := range ids {
+		items[i] = map[string]any{
+			"videoRenderer": map[string]any{
+				"videoId": id,
+				"title":   map[string]any{"runs": []any{map[string]any{"text": "Title " + id}}},
+				"ownerText": map[string]any{"runs": []any{map[string]any{
+					"text": "Artist " + id,
+					"navigationEndpoint": map[string]any{
+						"browseEndpoint": map[string]any{"browseId": "UC" + id, "canonicalBaseUrl": "/@artist" + id},
+					},
+				}}},
+				"lengthText":        map[string]any{"simpleText": "1:02:03"},
+				"viewCountText":     map[string]any{"simpleText": "1,234,567 views"},
+				"publishedTimeText": map[string]any{"simpleText": "2 years ago"},
+				"thumbnail": map[string]any{"thumbnails": []any{
+					map[string]any{"url": "https://i.ytimg.com/vi/" + id + "/default.jpg"},
+					map[string]any{"url": "https://i.ytimg.com/vi/" + id + "/hqdefault.jpg"},
+				}},
+			},
+		}
+	}
+	return items
+}
+
+func sectionContents(token string, ids ...string) []any {
+	items := videoItems(ids...)
+	if token != "" {
+		items = append(items, map[string]any{
+			"playlistRenderer": map[string]any{
+				"playlistId":      "PLmix",
+				"title":           map[string]any{"simpleText": "Lofi Mix"},
+				"shortBylineText": map[string]any{"runs": []any{map[string]any{"text": "Curator"}}},
+				"videoCount":      "1,024",
+			},
+		})
+	}
+	contents := []any{map[string]any{"itemSectionRenderer": map[string]any{"contents": items}}}
+	if token != "" {
+		contents = append(contents, map[string]any{
+			"continuationItemRenderer": map[string]any{
+				"continuationEndpoint": map[string]any{
+					"continuationCommand": map[string]any{"token": token},
+				},
+			},
+		})
+	}
+	return contents
+}
+
+func newSearchServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /results", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "lofi beats", r.URL.Query().Get("search_query"))
+		data, _ := json.Marshal(map[string]any{
+			"contents": map[string]any{
+				"twoColumnSearchResultsRenderer": map[string]any{
+					"primaryContents": map[string]any{
+						"sectionListRenderer": map[string]any{"contents": sectionContents("page2", "a", "b")},
+					},
+				},
+			},
+		})
+		fmt.Fprintf(w, `<html><script>ytcfg.set({"INNERTUBE_CLIENT_VERSION":"2.99990101.00.00"});</script>`)
+		fmt.Fprintf(w, `<script>var ytInitialData = %s;</script></html>`, data)
+	})
+	mux.HandleFunc("POST /youtubei/v1/search", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var req struct {
+			Context struct {
+				Client struct {
+					ClientName    string `json:"clientName"`
+					ClientVersion string `json:"clientVersion"`
+				} `json:"client"`
+			} `json:"context"`
+			Continuation string `json:"continuation"`
+		}
+		require.NoError(t, json.Unmarshal(body, &req))
+		require.Equal(t, "WEB", req.Context.Client.ClientName)
+		require.Equal(t, "2.99990101.00.00", req.Context.Client.ClientVersion)
+
+		var contents []any
+		switch req.Continuation {
+		case "page2":
+			contents = sectionContents("page3", "c", "d")
+		case "page3":
+			contents = sectionContents("", "e")
+		default:
+			http.Error(w, "unknown token", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"onResponseReceivedCommands": []any{
+				map[string]any{"appendContinuationItemsAction": map[string]any{"continuationItems": contents}},
+			},
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSearchPage(t *testing.T) {
+	server := newSearchServer(t)
+	client := newYoutubeClient("", server.URL)
+
+	page, err := client.SearchPage(context.Background(), "lofi beats", "")
+	require.NoError(t, err)
+	require.Len(t, page.Songs, 2)
+	require.Equal(t, "a", page.Songs[0].ID)
+	require.Equal(t, "Title a", page.Songs[0].Title)
+	require.Equal(t, []string{"Artist a"}, page.Songs[0].Artists)
+	require.Equal(t, 3723, page.Songs[0].Duration)
+	require.Equal(t, int64(1234567), page.Songs[0].Views)
+	require.Empty(t, page.Songs[0].UploadDate, "relative publish times should not be stored as upload dates")
+	require.Equal(t, "UCa", page.Songs[0].ChannelID)
+	require.Equal(t, "https://www.youtube.com/@artista", page.Songs[0].ChannelURL)
+	require.Equal(t, "https://i.ytimg.com/vi/a/hqdefault.jpg", page.Songs[0].Thumbnail)
+	require.Equal(t, "page2", page.Continuation)
+	require.Len(t, page.Playlists, 1)
+	require.Equal(t, "PLmix", page.Playlists[0].ID)
+	require.Equal(t, "Lofi Mix", page.Playlists[0].Title)
+	require.Equal(t, "Curator", page.Playlists[0].Author)
+	require.Equal(t, 1024, page.Playlists[0].Count)
+
+	page, err = client.SearchPage(context.Background(), "lofi beats", page.Continuation)
+	require.NoError(t, err)
+	require.Equal(t, "c", page.Songs[0].ID)
+	require.Equal(t, "page3", page.Continuation)
+	require.Len(t, page.Playlists, 1, "playlists on later pages should be kept")
+	require.Equal(t, "PLmix", page.Playlists[0].ID)
+
+	page, err = client.SearchPage(context.Background(), "lofi beats", page.Continuation)
+	require.NoError(t, err)
+	require.Len(t, page.Songs, 1)
+	require.Empty(t, page.Continuation)
+
+	_, err = client.SearchPage(context.Background(), "lofi beats", "bogus")
+	require.Error(t, err)
+}
+
+func TestSearchFollowsContinuations(t *testing.T) {
+	server := newSearchServer(t)
+	client := newYoutubeClient("", server.URL)
+
+	songs, err := client.Search(context.Background(), "lofi beats", 3)
+	require.NoError(t, err)
+	require.Len(t, songs, 3)
+	require.Equal(t, "c", songs[2].ID)
+
+	songs, err = client.Search(context.Background(), "lofi beats", 50)
+	require.NoError(t, err)
+	require.Len(t, songs, 5)
+}
+
+func TestGetSongInfoFromYTDLP(t *testing.T) {
+	execCommand = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "echo", `{"id":"abc","title":"Song","channel":"Artist","channel_id":"UCabc","channel_url":"https://www.youtube.com/channel/UCabc","duration":185.4,"thumbnail":"https://i.ytimg.com/vi/abc/maxresdefault.jpg","view_count":42,"upload_date":"20230115"}`)
+	}
+	t.Cleanup(func() { execCommand = exec.CommandContext })
+
+	song, err := newYoutubeClient("", defaultBaseURL).GetSongInfo(context.Background(), "https://www.youtube.com/watch?v=abc")
+	require.NoError(t, err)
+	require.Equal(t, []string{"Artist"}, song.Artists)
+	require.Equal(t, 185, song.Duration)
+	require.Equal(t, "UCabc", song.ChannelID)
+	require.Equal(t, "https://www.youtube.com/channel/UCabc", song.ChannelURL)
+	require.Equal(t, int64(42), song.Views)
+	require.Equal(t, "2023-01-15", song.UploadDate)
+}
+
+func TestGetPlaylist(t *testing.T) {
+	var gotArgs []string
+	execCommand = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		gotArgs = args
+		return exec.CommandContext(ctx, "echo", `{"id":"PLabc","title":"Mix","channel":"Curator","playlist_count":3,"entries":[`+
+			`{"id":"v1","title":"One","channel":"A","duration":61,"thumbnails":[{"url":"small.jpg"},{"url":"big.jpg"}]},`+
+			`{"id":"v2","title":"[Private video]"},`+
+			`{"id":"v3","title":"Three","uploader":"B"}]}`)
+	}
+	t.Cleanup(func() { execCommand = exec.CommandContext })
+
+	client := newYoutubeClient("", defaultBaseURL)
+	playlist, err := client.GetPlaylist("PLabc")
+	require.NoError(t, err)
+	require.Equal(t, []string{"--flat-playlist", "-J", "--", "https://www.youtube.com/playlist?list=PLabc"}, gotArgs)
+	require.Equal(t, "Mix", playlist.Title)
+	require.Equal(t, "Curator", playlist.Author)
+	require.Equal(t, 3, playlist.Count)
+	require.Len(t, playlist.Songs, 2)
+	require.Equal(t, "v1", playlist.Songs[0].ID)
+	require.Equal(t, 61, playlist.Songs[0].Duration)
+	require.Equal(t, "big.jpg", playlist.Songs[0].Thumbnail)
+	require.Equal(t, []string{"B"}, playlist.Songs[1].Artists)
+
+	page, err := client.SearchPage(context.Background(), "https://www.youtube.com/playlist?list=PLabc", "")
+	require.NoError(t, err)
+	require.Empty(t, page.Songs)
+	require.Len(t, page.Playlists, 1)
+	require.Equal(t, "PLabc", page.Playlists[0].ID)
+
+	_, err = client.GetPlaylist("https://www.youtube.com/channel/UCabc/videos")
+	require.NoError(t, err)
+	require.Equal(t, []string{"--flat-playlist", "-J", "--playlist-end", "50", "--", "https://www.youtube.com/channel/UCabc/videos"}, gotArgs, "artist channels should be capped")
+}
+
+func TestIsPlaylistURL(t *testing.T) {
+	require.True(t, isPlaylistURL("https://www.youtube.com/playlist?list=PLabc"))
+	require.True(t, isPlaylistURL("https://music.youtube.com/playlist?list=PLabc&si=x"))
+	require.False(t, isPlaylistURL("https://www.youtube.com/watch?v=abc&list=PLabc"))
+	require.False(t, isPlaylistURL("https://www.youtube.com/watch?v=abc"))
+	require.False(t, isPlaylistURL("lofi list=PLabc"))
+}
+
+func TestSuggest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "daft", r.URL.Query().Get("q"))
+		require.Equal(t, "yt", r.URL.Query().Get("ds"))
+		fmt.Fprint(w, `["daft",["daft punk","daft punk \"one more time\""],[],{"google:suggestsubtypes":[[512],[512]]}]`)
+	}))
+	defer server.Close()
+
+	client := newYoutubeClient("", defaultBaseURL)
+	client.suggestURL = server.URL
+	suggestions, err := client.Suggest("daft")
+	require.NoError(t, err)
+	require.Equal(t, []string{"daft punk", `daft punk "one more time"`}, suggestions)
+}
+
+func TestSearchTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	client := newYoutubeClient("", server.URL)
+	client.searchTimeout = 50 * time.Millisecond
+
+	_, err := client.SearchPage(context.Background(), "lofi beats", "")
+	var timeoutErr *TimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, "search timed out after 50ms", err.Error())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.Search(ctx, "lofi beats", 10)
+	require.ErrorIs(t, err, context.Canceled)
+	require.NotErrorAs(t, err, &timeoutErr)
+}
+
+func TestGetSongInfoTypedErrors(t *testing.T) {
+	cases := map[string]error{
+		"ERROR: [youtube] abc: Private video. Sign in if you've been granted access to this video":                          ErrPrivateVideo,
+		"ERROR: [youtube] abc: Sign in to confirm your age. This video may be inappropriate for some users.":                ErrAgeRestricted,
+		"ERROR: [youtube] abc: The uploader has not made this video available in your country":                              ErrGeoBlocked,
+		"WARNING: [youtube] retrying\nERROR: [youtube] abc: Video unavailable. This video has been removed by the uploader": ErrUnavailable,
+	}
+	for stderr, want := range cases {
+		fakeYTDLP(t, "printf '%s\\n' \""+stderr+"\" >&2; exit 1")
+
+		_, err := newYoutubeClient("", defaultBaseURL).GetSongInfo(context.Background(), "https://www.youtube.com/watch?v=abc")
+		require.ErrorIs(t, err, want)
+		require.Equal(t, want.Error(), err.Error())
+	}
+
+	fakeYTDLP(t, "echo 'ERROR: Unsupported URL: https://example.com' >&2; exit 1")
+	_, err := newYoutubeClient("", defaultBaseURL).GetSongInfo(context.Background(), "https://example.com")
+	var ytdlpErr *YTDLPError
+	require.ErrorAs(t, err, &ytdlpErr)
+	require.Nil(t, ytdlpErr.Kind)
+	require.Equal(t, "yt-dlp failed with: Unsupported URL: https://example.com", err.Error())
+}
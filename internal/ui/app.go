@@ -2,68 +2,256 @@ package ui
 
 import (
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 	"yogo/internal/domain"
 	"yogo/internal/logger"
 	"yogo/internal/ports"
+	"yogo/internal/services/control"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
+const (
+	volumeStep    = 5
+	noticeTimeout = 5 * time.Second
+)
+
+type noticeTickMsg struct{ err error }
+
 type activeView int
 
 const (
 	searchView activeView = iota
 	historyView
+	queueView
+	playlistView
 )
 
 type AppModel struct {
 	width, height  int
 	styles         Styles
+	keys           keyMap
+	help           help.Model
+	showHelp       bool
 	focus          ports.FocusState
 	activeView     activeView
 	config         domain.Config
-	playerService  ports.PlayerService
+	configService  ports.ConfigService
+	ytService      ports.YoutubeService
+	playerService  ports.PlaybackService
 	storageService ports.StorageService
+	queueService   ports.QueueService
+	radio          ports.RadioService
+	commands       *control.Commands
+	playerEvents   <-chan ports.PlayerEvent
+	bus            ports.EventBus
+	events         <-chan ports.Event
 	search         listAndFilterModel
 	history        listAndFilterModel
+	queue          listAndFilterModel
+	playlist       listAndFilterModel
 	player         PlayerModel
+	plays          *playGate
+}
+
+type playGate struct {
+	mu     sync.Mutex
+	latest atomic.Uint64
 }
 
-func InitialModel(ytService ports.YoutubeService, pService ports.PlayerService, sService ports.StorageService, cfg domain.Config) AppModel {
+func InitialModel(ytService ports.YoutubeService, pService ports.PlaybackService, sService ports.StorageService, qService ports.QueueService, radio ports.RadioService, cService ports.ConfigService, cfg domain.Config, bus ports.EventBus) AppModel {
 	styles := DefaultStyles()
+	keys := newKeyMap(cfg.Keys)
+	player := NewPlayerModel()
+	if state, err := pService.GetState(); err == nil {
+		player.state = state
+	}
+	player.autoplay = radio.Enabled()
 	return AppModel{
 		styles:         styles,
+		keys:           keys,
+		help:           help.New(),
 		focus:          ports.GlobalFocus,
 		activeView:     searchView,
 		config:         cfg,
+		configService:  cService,
+		ytService:      ytService,
 		playerService:  pService,
 		storageService: sService,
-		search:         NewSearchModel(ytService, cfg, styles),
-		history:        NewHistoryModel(sService, cfg, styles),
-		player:         NewPlayerModel(),
+		queueService:   qService,
+		radio:          radio,
+		commands:       control.NewCommands(ytService, pService, sService, qService, radio, cfg.HistoryLimit),
+		playerEvents:   pService.Subscribe(),
+		bus:            bus,
+		events:         bus.Subscribe(),
+		search:         NewSearchModel(ytService, sService, cfg, styles, keys),
+		history:        NewHistoryModel(sService, cfg, styles, keys),
+		queue:          NewQueueModel(qService, styles, keys),
+		playlist:       NewPlaylistModel(ytService, domain.Playlist{}, styles, keys),
+		player:         player,
+		plays:          &playGate{},
+	}
+}
+
+func (m *AppModel) Resume(song domain.Song) {
+	status := statusPaused
+	if m.player.state.IsPlaying {
+		status = statusPlaying
+	}
+	m.player.SetContent(status, song, nil)
+	m.playerService.Resume(song)
+}
+
+func (m *AppModel) play(song domain.Song, url string) tea.Cmd {
+	gate, playerService := m.plays, m.playerService
+	seq := gate.latest.Add(1)
+	return func() tea.Msg {
+		gate.mu.Lock()
+		defer gate.mu.Unlock()
+		if gate.latest.Load() != seq {
+			return nil
+		}
+		if err := playerService.PlaySong(song, url); err != nil {
+			return ports.PlayErrorMsg{Song: song, Err: err}
+		}
+		return ports.SongNowPlayingMsg{Song: song}
+	}
+}
+
+func (m *AppModel) activeComponent() *listAndFilterModel {
+	switch m.activeView {
+	case historyView:
+		return &m.history
+	case queueView:
+		return &m.queue
+	case playlistView:
+		return &m.playlist
+	default:
+		return &m.search
+	}
+}
+
+func (m *AppModel) syncLoop() {
+	mode := m.config.Playback.LoopMode
+	m.player.loopSuspended = mode == domain.LoopInfinite && (m.player.autoplay || len(m.queueService.List()) > 0)
+	if m.player.loopSuspended {
+		mode = domain.LoopOff
+	}
+	if err := m.playerService.SetLoop(mode, m.config.Playback.RepeatCount); err != nil {
+		logger.Log.Error().Err(err).Msg("Failed to update loop mode")
+	}
+}
+
+func (m *AppModel) cycleLoop() {
+	switch m.config.Playback.LoopMode {
+	case domain.LoopOff:
+		m.config.Playback.LoopMode = domain.LoopInfinite
+	case domain.LoopInfinite:
+		m.config.Playback.LoopMode = domain.LoopRepeat
+	default:
+		m.config.Playback.LoopMode = domain.LoopOff
+	}
+	m.config.Playback.Loop = m.config.Playback.LoopMode != domain.LoopOff
+	m.syncLoop()
+	if err := m.configService.SavePlayback(m.config.Playback); err != nil {
+		logger.Log.Error().Err(err).Msg("Failed to save playback settings")
+	}
+}
+
+func (m *AppModel) queueChanged(err error) tea.Cmd {
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("Failed to update queue")
+	}
+	m.syncLoop()
+	return m.queue.Init()
+}
+
+func (m *AppModel) toggleAutoplay() {
+	enabled := !m.player.autoplay
+	if err := m.radio.SetEnabled(enabled); err != nil {
+		logger.Log.Error().Err(err).Msg("Failed to toggle autoplay")
+		return
+	}
+	m.player.autoplay = enabled
+	m.config.Playback.Autoplay = enabled
+	m.syncLoop()
+	if err := m.configService.SavePlayback(m.config.Playback); err != nil {
+		logger.Log.Error().Err(err).Msg("Failed to save autoplay setting")
+	}
+}
+
+func (m *AppModel) autoplay() tea.Cmd {
+	seed := m.player.song
+	if !m.player.autoplay || seed.ID == "" {
+		return nil
 	}
+	commands := m.commands
+	return func() tea.Msg {
+		commands.Autoplay(seed)
+		return ports.RadioFilledMsg{Seed: seed}
+	}
+}
+
+func (m *AppModel) playNextInQueue() tea.Cmd {
+	song, ok := m.commands.Next()
+	if !ok {
+		return nil
+	}
+	return tea.Batch(
+		m.queue.Init(),
+		func() tea.Msg { return ports.PlaySongMsg{Song: song} },
+	)
 }
 
 func (m *AppModel) savePositionAndQuit() tea.Cmd {
+	state, err := m.playerService.GetState()
+	if err != nil {
+		return tea.Quit
+	}
+
 	if m.config.Playback.SavePositionOnQuit && (m.player.status == statusPlaying || m.player.status == statusPaused) {
-		state, err := m.playerService.GetState()
-		if err == nil && state.Position > 0 {
+		if state.Position > 0 {
 			m.storageService.UpdateHistoryEntryPosition(m.player.song.ID, int(state.Position))
 		}
 	}
 	return tea.Quit
 }
 
-func tickCmd() tea.Cmd {
-	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
-		return ports.TickMsg(t)
-	})
+func waitForPlayerEvent(events <-chan ports.PlayerEvent) tea.Cmd {
+	return func() tea.Msg {
+		event, ok := <-events
+		if !ok {
+			return nil
+		}
+		switch event.Type {
+		case ports.PlayerTrackEnded:
+			return ports.PlaybackEndedMsg{Err: event.Err}
+		case ports.PlayerProcessExited:
+			return ports.PlayerCrashedMsg{Err: event.Err}
+		case ports.PlayerRecovered:
+			return ports.PlayerRecoveredMsg{}
+		}
+		return ports.PlayerStateUpdateMsg{State: event.State}
+	}
+}
+
+func waitForEvent(events <-chan ports.Event) tea.Cmd {
+	return func() tea.Msg {
+		event, ok := <-events
+		if !ok {
+			return nil
+		}
+		return event
+	}
 }
 
 func (m AppModel) Init() tea.Cmd {
-	return tea.Batch(m.search.Init(), m.history.Init(), tickCmd())
+	return tea.Batch(m.search.Init(), m.history.Init(), m.queue.Init(), waitForPlayerEvent(m.playerEvents), waitForEvent(m.events))
 }
 
 func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -79,16 +267,12 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case ports.ChangeFocusMsg:
 		m.focus = msg.NewFocus
 		if m.focus == ports.ComponentFocus {
-			var activeComponent *listAndFilterModel
-			if m.activeView == searchView {
-				activeComponent = &m.search
-			} else {
-				activeComponent = &m.history
-			}
-			cmd = activeComponent.Focus()
+			cmd = m.activeComponent().Focus()
 		} else {
 			m.search.Blur()
 			m.history.Blur()
+			m.queue.Blur()
+			m.playlist.Blur()
 		}
 		cmds = append(cmds, cmd)
 		return m, tea.Batch(cmds...)
@@ -96,6 +280,7 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case ports.PlaySongMsg:
 		m.focus = ports.GlobalFocus
 		m.player.SetContent(statusLoading, msg.Song, nil)
+		m.syncLoop()
 
 		var resumeAt int
 		if m.config.Playback.SavePositionOnQuit {
@@ -109,12 +294,7 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			youtubeURL = fmt.Sprintf("%s&t=%ds", youtubeURL, resumeAt)
 		}
 
-		err := m.playerService.Play(youtubeURL)
-		if err != nil {
-			cmds = append(cmds, func() tea.Msg { return ports.PlayErrorMsg{Err: err} })
-		} else {
-			cmds = append(cmds, func() tea.Msg { return ports.SongNowPlayingMsg(msg) })
-		}
+		cmds = append(cmds, m.play(msg.Song, youtubeURL))
 
 		go m.storageService.AddToHistory(domain.HistoryEntry{Song: msg.Song})
 
@@ -130,75 +310,218 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return nil
 			})
 		}
-		cmds = append(cmds, tea.Sequence(tea.Batch(deleteCmds...), m.history.Init()))
+		cmds = append(cmds, tea.Batch(deleteCmds...))
+
+	case ports.QueueLoadedMsg:
+		m.queue, cmd = m.queue.Update(msg)
+		return m, cmd
+
+	case ports.SearchMoreResultsMsg, ports.SearchMoreErrorMsg, suggestTickMsg, ports.SuggestionsMsg:
+		m.search, cmd = m.search.Update(msg)
+		return m, cmd
+
+	case ports.OpenPlaylistMsg:
+		m.search, _ = m.search.Update(msg)
+		m.playlist = NewPlaylistModel(m.ytService, msg.Playlist, m.styles, m.keys)
+		m.playlist.focus = listFocus
+		m.activeView = playlistView
+		m.focus = ports.ComponentFocus
+		return m, m.playlist.Init()
+
+	case ports.PlaylistLoadedMsg, ports.PlaylistErrorMsg:
+		m.playlist, cmd = m.playlist.Update(msg)
+		return m, cmd
+
+	case ports.PlayPlaylistMsg:
+		if len(msg.Songs) == 0 {
+			return m, nil
+		}
+		var err error
+		for i := len(msg.Songs) - 1; i > 0 && err == nil; i-- {
+			err = m.queueService.PlayNext(msg.Songs[i])
+		}
+		cmds = append(cmds,
+			m.queueChanged(err),
+			func() tea.Msg { return ports.PlaySongMsg{Song: msg.Songs[0]} },
+		)
+
+	case ports.QueueSongMsg:
+		var err error
+		if msg.PlayNext {
+			err = m.queueService.PlayNext(msg.Song)
+		} else {
+			err = m.queueService.Enqueue(msg.Song)
+		}
+		cmds = append(cmds, m.queueChanged(err))
+
+	case ports.PlayQueueItemMsg:
+		songs := m.queueService.List()
+		if msg.Index >= 0 && msg.Index < len(songs) {
+			song := songs[msg.Index]
+			cmds = append(cmds,
+				m.queueChanged(m.queueService.Remove(msg.Index)),
+				func() tea.Msg { return ports.PlaySongMsg{Song: song} },
+			)
+		}
+
+	case ports.RemoveFromQueueMsg:
+		cmds = append(cmds, m.queueChanged(m.queueService.Remove(msg.Index)))
+
+	case ports.MoveInQueueMsg:
+		cmds = append(cmds, m.queueChanged(m.queueService.Move(msg.From, msg.To)))
+
+	case ports.ClearQueueMsg:
+		cmds = append(cmds, m.queueChanged(m.queueService.Clear()))
+
+	case ports.PlaybackEndedMsg:
+		cmds = append(cmds, waitForPlayerEvent(m.playerEvents))
+		if msg.Err != nil {
+			logger.Log.Error().Err(msg.Err).Str("songID", m.player.song.ID).Msg("Playback failed")
+		}
+		if next := m.playNextInQueue(); next != nil {
+			cmds = append(cmds, next)
+		} else if fill := m.autoplay(); fill != nil {
+			cmds = append(cmds, fill)
+		} else if msg.Err != nil {
+			m.player.SetContent(statusError, m.player.song, msg.Err)
+			m.playerService.SetSong(domain.Song{})
+		} else {
+			m.player.SetContent(statusIdle, domain.Song{}, nil)
+			m.playerService.SetSong(domain.Song{})
+		}
+
+	case ports.RadioFilledMsg:
+		if m.player.song.ID != msg.Seed.ID {
+			return m, nil
+		}
+		if next := m.playNextInQueue(); next != nil {
+			cmds = append(cmds, next)
+		} else {
+			m.player.SetContent(statusIdle, domain.Song{}, nil)
+			m.playerService.SetSong(domain.Song{})
+		}
 
 	case ports.SongNowPlayingMsg:
+		if m.player.song.ID != msg.Song.ID {
+			return m, nil
+		}
 		m.player.SetContent(statusPlaying, msg.Song, nil)
 
 	case ports.PlayErrorMsg:
+		if m.player.song.ID != msg.Song.ID {
+			return m, nil
+		}
 		m.player.SetContent(statusError, domain.Song{}, msg.Err)
 
-	case ports.TickMsg:
-		if m.player.status == statusPlaying || m.player.status == statusPaused {
-			state, err := m.playerService.GetState()
-			if err == nil {
-				cmds = append(cmds, func() tea.Msg { return ports.PlayerStateUpdateMsg{State: state} })
-			}
+	case ports.RemotePlayErrorMsg:
+		if m.player.status == statusIdle || m.player.status == statusError {
+			m.player.SetContent(statusError, domain.Song{}, msg.Err)
+			return m, nil
+		}
+		m.player.notice = msg.Err
+		cmds = append(cmds, tea.Tick(noticeTimeout, func(time.Time) tea.Msg {
+			return noticeTickMsg{err: msg.Err}
+		}))
+
+	case noticeTickMsg:
+		if m.player.notice == msg.err {
+			m.player.notice = nil
+		}
+
+	case ports.HistoryChangedEvent:
+		cmds = append(cmds, waitForEvent(m.events), m.history.Init())
+
+	case ports.Event:
+		cmds = append(cmds, waitForEvent(m.events))
+
+	case ports.RemoteCommandMsg:
+		cmds = append(cmds, m.handleRemoteCommand(msg))
+
+	case ports.PlayerCrashedMsg:
+		cmds = append(cmds, waitForPlayerEvent(m.playerEvents))
+		if m.player.status != statusIdle {
+			m.player.SetContent(statusError, m.player.song, msg.Err)
+		}
+
+	case ports.PlayerRecoveredMsg:
+		cmds = append(cmds, waitForPlayerEvent(m.playerEvents))
+		if m.player.song.ID != "" {
+			m.player.SetContent(statusPlaying, m.player.song, nil)
 		}
-		cmds = append(cmds, tickCmd())
 
 	case ports.PlayerStateUpdateMsg:
-		m.player, cmd = m.player.Update(msg)
-		cmds = append(cmds, cmd)
+		cmds = append(cmds, waitForPlayerEvent(m.playerEvents))
 
 	case tea.KeyMsg:
+		helpAvailable := m.focus == ports.GlobalFocus || m.activeComponent().GetFocus() == listFocus
+		if helpAvailable && key.Matches(msg, m.keys.Help) {
+			m.showHelp = !m.showHelp
+			return m, nil
+		}
+		if m.showHelp && key.Matches(msg, m.keys.Back) {
+			m.showHelp = false
+			return m, nil
+		}
+
 		if m.focus == ports.GlobalFocus {
-			switch msg.String() {
-			case "ctrl+c", "q":
+			switch {
+			case key.Matches(msg, m.keys.Quit):
 				return m, m.savePositionAndQuit()
-			case "s":
+			case key.Matches(msg, m.keys.Search):
 				m.activeView = searchView
 				return m, func() tea.Msg { return ports.ChangeFocusMsg{NewFocus: ports.ComponentFocus} }
-			case "h":
+			case key.Matches(msg, m.keys.History):
 				m.activeView = historyView
 				cmds = append(cmds, m.history.Init())
 				cmds = append(cmds, func() tea.Msg { return ports.ChangeFocusMsg{NewFocus: ports.ComponentFocus} })
 				return m, tea.Batch(cmds...)
-			case " ":
+			case key.Matches(msg, m.keys.Queue):
+				m.activeView = queueView
+				cmds = append(cmds, m.queue.Init())
+				cmds = append(cmds, func() tea.Msg { return ports.ChangeFocusMsg{NewFocus: ports.ComponentFocus} })
+				return m, tea.Batch(cmds...)
+			case key.Matches(msg, m.keys.SkipNext):
+				cmds = append(cmds, m.playNextInQueue())
+			case key.Matches(msg, m.keys.PlayPause):
 				if m.player.status == statusPlaying || m.player.status == statusPaused {
 					m.playerService.Pause()
 				}
-			case "right":
+			case key.Matches(msg, m.keys.SeekForward):
 				if m.player.status == statusPlaying || m.player.status == statusPaused {
 					m.playerService.Seek(5)
 				}
-			case "left":
+			case key.Matches(msg, m.keys.SeekBackward):
 				if m.player.status == statusPlaying || m.player.status == statusPaused {
 					m.playerService.Seek(-5)
 				}
-			case "]":
+			case key.Matches(msg, m.keys.SpeedUp):
 				if m.player.status == statusPlaying || m.player.status == statusPaused {
 					m.playerService.ChangeSpeed(0.25)
 				}
-			case "[":
+			case key.Matches(msg, m.keys.SpeedDown):
 				if m.player.status == statusPlaying || m.player.status == statusPaused {
 					m.playerService.ChangeSpeed(-0.25)
 				}
-			case `\`:
+			case key.Matches(msg, m.keys.SpeedReset):
 				if m.player.status == statusPlaying || m.player.status == statusPaused {
 					m.playerService.ResetSpeed()
 				}
+			case key.Matches(msg, m.keys.VolumeUp):
+				m.playerService.ChangeVolume(volumeStep)
+			case key.Matches(msg, m.keys.VolumeDown):
+				m.playerService.ChangeVolume(-volumeStep)
+			case key.Matches(msg, m.keys.Mute):
+				m.playerService.ToggleMute()
+			case key.Matches(msg, m.keys.CycleLoop):
+				m.cycleLoop()
+			case key.Matches(msg, m.keys.Autoplay):
+				m.toggleAutoplay()
 			}
 		}
 	}
 
 	if m.focus == ports.ComponentFocus {
-		var activeComponent *listAndFilterModel
-		if m.activeView == searchView {
-			activeComponent = &m.search
-		} else {
-			activeComponent = &m.history
-		}
+		activeComponent := m.activeComponent()
 		*activeComponent, cmd = activeComponent.Update(msg)
 		cmds = append(cmds, cmd)
 	}
@@ -217,15 +540,10 @@ func (m AppModel) View() string {
 	appWidth := m.width - m.styles.App.GetHorizontalFrameSize()
 	appHeight := m.height - m.styles.App.GetVerticalFrameSize()
 
-	footerHeight := 4
+	activeComponent := m.activeComponent()
+	footerHeight := 4 + len(activeComponent.suggestions)
 	mainPanelHeight := appHeight - footerHeight
 
-	var activeComponent *listAndFilterModel
-	if m.activeView == searchView {
-		activeComponent = &m.search
-	} else {
-		activeComponent = &m.history
-	}
 	activeComponent.SetSize(appWidth, mainPanelHeight)
 	m.player.SetSize(appWidth)
 
@@ -233,6 +551,18 @@ func (m AppModel) View() string {
 	internalFocus := activeComponent.GetFocus()
 	playerFooterContent := m.player.View()
 
+	if m.showHelp {
+		var groups [][]key.Binding
+		if m.focus == ports.GlobalFocus {
+			groups = m.keys.playerHelp()
+		} else {
+			source, filterable := activeComponent.dataSource.(filterableDataSource)
+			groups = m.keys.listHelp(m.activeView, internalFocus, filterable && source.Filter() != "")
+		}
+		m.help.Width = appWidth - 2
+		mainContent = m.help.FullHelpView(groups)
+	}
+
 	var mainPanelStyle, footerPanelStyle lipgloss.Style
 	var footerTitle string
 
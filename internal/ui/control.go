@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"sync/atomic"
+	"time"
+	"yogo/internal/ports"
+	"yogo/internal/services/control"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const remoteCommandTimeout = 25 * time.Second
+
+type ProgramControlHandler struct {
+	program atomic.Pointer[tea.Program]
+}
+
+func NewProgramControlHandler() *ProgramControlHandler {
+	return &ProgramControlHandler{}
+}
+
+func (h *ProgramControlHandler) Attach(program *tea.Program) {
+	h.program.Store(program)
+}
+
+func (h *ProgramControlHandler) Handle(req ports.ControlRequest) ports.ControlResponse {
+	program := h.program.Load()
+	if program == nil {
+		return ports.ControlResponse{Error: "yogo is still starting"}
+	}
+	reply := make(chan ports.ControlResponse, 1)
+	program.Send(ports.RemoteCommandMsg{Request: req, Reply: reply})
+	select {
+	case resp := <-reply:
+		return resp
+	case <-time.After(remoteCommandTimeout):
+		return ports.ControlResponse{Error: "timed out waiting for yogo to handle the command"}
+	}
+}
+
+func (s playerStatus) String() string {
+	switch s {
+	case statusLoading:
+		return "loading"
+	case statusPlaying:
+		return "playing"
+	case statusPaused:
+		return "paused"
+	case statusError:
+		return "error"
+	default:
+		return "idle"
+	}
+}
+
+func (m *AppModel) handleRemoteCommand(msg ports.RemoteCommandMsg) tea.Cmd {
+	switch msg.Request.Command {
+	case "play":
+		return m.playRemoteURL(control.Arg(msg.Request), msg.Reply)
+	case "next":
+		next := m.playNextInQueue()
+		if next == nil {
+			msg.Reply <- control.Result(control.ErrQueueEmpty)
+		} else {
+			msg.Reply <- control.Result(nil)
+		}
+		return next
+	}
+	resp := m.commands.Run(msg.Request, ports.ControlStatus{Status: m.player.status.String(), Song: m.player.song})
+	if msg.Request.Command == "stop" && resp.OK {
+		m.player.SetContent(statusIdle, m.player.song, nil)
+	}
+	msg.Reply <- resp
+	return nil
+}
+
+func (m *AppModel) playRemoteURL(url string, reply chan<- ports.ControlResponse) tea.Cmd {
+	commands := m.commands
+	return func() tea.Msg {
+		song, err := commands.Lookup(url)
+		reply <- control.Result(err)
+		if err != nil {
+			return ports.RemotePlayErrorMsg{Err: err}
+		}
+		return ports.PlaySongMsg{Song: song}
+	}
+}
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"context"
 	"yogo/internal/domain"
 	"yogo/internal/ports"
 
@@ -19,7 +20,7 @@ type historyDataSource struct {
 	config         domain.Config
 }
 
-func (s historyDataSource) Fetch(query string) tea.Msg {
+func (s historyDataSource) Fetch(ctx context.Context, query string) tea.Msg {
 	entries, err := s.storageService.GetHistory(s.config.HistoryLimit)
 	if err != nil {
 		return ports.HistoryErrorMsg{Err: err}
@@ -27,11 +28,12 @@ func (s historyDataSource) Fetch(query string) tea.Msg {
 	return ports.HistoryLoadedMsg{Entries: entries}
 }
 
-func NewHistoryModel(service ports.StorageService, cfg domain.Config, styles Styles) listAndFilterModel {
+func NewHistoryModel(service ports.StorageService, cfg domain.Config, styles Styles, keys keyMap) listAndFilterModel {
 	return NewListAndFilterModel(
 		"history",
 		"Filter history...",
 		historyDataSource{storageService: service, config: cfg},
 		styles,
+		keys,
 	)
 }
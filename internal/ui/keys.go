@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"strings"
+	"yogo/internal/domain"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+type keyMap struct {
+	Quit         key.Binding
+	Help         key.Binding
+	Search       key.Binding
+	History      key.Binding
+	Queue        key.Binding
+	PlayPause    key.Binding
+	SeekForward  key.Binding
+	SeekBackward key.Binding
+	SpeedUp      key.Binding
+	SpeedDown    key.Binding
+	SpeedReset   key.Binding
+	SkipNext     key.Binding
+	VolumeUp     key.Binding
+	VolumeDown   key.Binding
+	Mute         key.Binding
+	CycleLoop    key.Binding
+	Autoplay     key.Binding
+
+	Back        key.Binding
+	SwitchFocus key.Binding
+	Select      key.Binding
+	AddToQueue  key.Binding
+	PlayNext    key.Binding
+	Mark        key.Binding
+	Delete      key.Binding
+	MoveUp      key.Binding
+	MoveDown    key.Binding
+	ClearQueue  key.Binding
+	NextFilter  key.Binding
+}
+
+func newBinding(keys []string, desc string) key.Binding {
+	msgKeys := make([]string, len(keys))
+	for i, k := range keys {
+		if k == "space" {
+			k = " "
+		}
+		msgKeys[i] = k
+	}
+	return key.NewBinding(
+		key.WithKeys(msgKeys...),
+		key.WithHelp(strings.Join(keys, "/"), desc),
+	)
+}
+
+func newKeyMap(cfg domain.KeysConfig) keyMap {
+	return keyMap{
+		Quit:         newBinding(cfg.Quit, "quit"),
+		Help:         newBinding(cfg.Help, "toggle help"),
+		Search:       newBinding(cfg.Search, "search"),
+		History:      newBinding(cfg.History, "history"),
+		Queue:        newBinding(cfg.Queue, "queue"),
+		PlayPause:    newBinding(cfg.PlayPause, "play/pause"),
+		SeekForward:  newBinding(cfg.SeekForward, "seek +5s"),
+		SeekBackward: newBinding(cfg.SeekBackward, "seek -5s"),
+		SpeedUp:      newBinding(cfg.SpeedUp, "speed up"),
+		SpeedDown:    newBinding(cfg.SpeedDown, "speed down"),
+		SpeedReset:   newBinding(cfg.SpeedReset, "reset speed"),
+		SkipNext:     newBinding(cfg.SkipNext, "next in queue"),
+		VolumeUp:     newBinding(cfg.VolumeUp, "volume up"),
+		VolumeDown:   newBinding(cfg.VolumeDown, "volume down"),
+		Mute:         newBinding(cfg.Mute, "mute"),
+		CycleLoop:    newBinding(cfg.CycleLoop, "loop off/on/repeat"),
+		Autoplay:     newBinding(cfg.Autoplay, "autoplay related songs"),
+
+		Back:        newBinding(cfg.Back, "back to player"),
+		SwitchFocus: newBinding(cfg.SwitchFocus, "switch input/list"),
+		Select:      newBinding(cfg.Select, "play"),
+		AddToQueue:  newBinding(cfg.AddToQueue, "add to queue"),
+		PlayNext:    newBinding(cfg.PlayNext, "play next"),
+		Mark:        newBinding(cfg.Mark, "mark for deletion"),
+		Delete:      newBinding(cfg.Delete, "delete"),
+		MoveUp:      newBinding(cfg.MoveUp, "move up"),
+		MoveDown:    newBinding(cfg.MoveDown, "move down"),
+		ClearQueue:  newBinding(cfg.ClearQueue, "clear queue"),
+		NextFilter:  newBinding(cfg.NextFilter, "next search filter"),
+	}
+}
+
+func (k keyMap) playerHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Search, k.History, k.Queue, k.Help, k.Quit},
+		{k.PlayPause, k.SeekForward, k.SeekBackward, k.SkipNext, k.CycleLoop, k.Autoplay},
+		{k.SpeedUp, k.SpeedDown, k.SpeedReset},
+		{k.VolumeUp, k.VolumeDown, k.Mute},
+	}
+}
+
+func (k keyMap) listHelp(view activeView, focus componentFocus, filterable bool) [][]key.Binding {
+	if focus == inputFocus {
+		bindings := []key.Binding{k.SwitchFocus, k.Back}
+		if view == searchView {
+			bindings = append([]key.Binding{k.Select}, bindings...)
+		}
+		if filterable {
+			bindings = append(bindings, k.NextFilter)
+		}
+		return [][]key.Binding{bindings}
+	}
+
+	var actions []key.Binding
+	switch view {
+	case searchView:
+		actions = []key.Binding{k.AddToQueue, k.PlayNext}
+		if filterable {
+			actions = append(actions, k.NextFilter)
+		}
+	case historyView:
+		actions = []key.Binding{k.AddToQueue, k.PlayNext, k.Mark, k.Delete}
+	case queueView:
+		actions = []key.Binding{k.MoveUp, k.MoveDown, k.Delete, k.ClearQueue}
+	case playlistView:
+		actions = []key.Binding{k.AddToQueue, k.PlayNext}
+	}
+	return [][]key.Binding{
+		{k.Select, k.SwitchFocus, k.Back, k.Help},
+		actions,
+	}
+}
@@ -2,9 +2,10 @@ package ui
 
 import (
 	"fmt"
+	"math"
 	"strings"
-	"time"
 	"yogo/internal/domain"
+	"yogo/internal/format"
 	"yogo/internal/ports"
 
 	"github.com/charmbracelet/bubbles/progress"
@@ -23,12 +24,15 @@ const (
 )
 
 type PlayerModel struct {
-	width    int
-	status   playerStatus
-	song     domain.Song
-	err      error
-	state    ports.PlayerState
-	progress progress.Model
+	width         int
+	status        playerStatus
+	song          domain.Song
+	err           error
+	notice        error
+	state         ports.PlayerState
+	autoplay      bool
+	loopSuspended bool
+	progress      progress.Model
 }
 
 func NewPlayerModel() PlayerModel {
@@ -50,8 +54,14 @@ func (m *PlayerModel) SetContent(status playerStatus, song domain.Song, err erro
 	m.status = status
 	m.song = song
 	m.err = err
+	m.notice = nil
 	if status != statusPlaying && status != statusPaused {
-		m.state = ports.PlayerState{}
+		m.state = ports.PlayerState{
+			Volume:      m.state.Volume,
+			Muted:       m.state.Muted,
+			Loop:        m.state.Loop,
+			RepeatCount: m.state.RepeatCount,
+		}
 		m.progress.SetPercent(0)
 	}
 }
@@ -61,6 +71,9 @@ func (m PlayerModel) Update(msg tea.Msg) (PlayerModel, tea.Cmd) {
 	switch msg := msg.(type) {
 	case ports.PlayerStateUpdateMsg:
 		m.state = msg.State
+		if m.status != statusPlaying && m.status != statusPaused {
+			return m, nil
+		}
 		if m.state.IsPlaying {
 			m.status = statusPlaying
 		} else if m.status == statusPlaying {
@@ -79,21 +92,6 @@ func (m PlayerModel) Update(msg tea.Msg) (PlayerModel, tea.Cmd) {
 	return m, nil
 }
 
-func formatDuration(seconds float64) string {
-	if seconds <= 0 {
-		return "00:00"
-	}
-	d := time.Duration(seconds) * time.Second
-	h := int(d.Hours())
-	m := int(d.Minutes()) % 60
-	s := int(d.Seconds()) % 60
-
-	if h > 0 {
-		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
-	}
-	return fmt.Sprintf("%02d:%02d", m, s)
-}
-
 func (m PlayerModel) ViewTitle() string {
 	playPauseSymbol := "▶"
 	if m.state.IsPlaying {
@@ -113,7 +111,31 @@ func (m PlayerModel) ViewTitle() string {
 
 	controls := fmt.Sprintf("« %s »", playPauseSymbol)
 
-	return fmt.Sprintf("Player | %s | %s", controls, speedStr)
+	volumeStr := fmt.Sprintf("vol %d%%", int(math.Round(m.state.Volume)))
+	if m.state.Muted {
+		volumeStr = "muted"
+	}
+
+	var loopStr string
+	switch {
+	case m.loopSuspended:
+		loopStr = "loop ∞ (suspended)"
+	case m.state.Loop == domain.LoopInfinite:
+		loopStr = "loop ∞"
+	case m.state.Loop == domain.LoopRepeat:
+		loopStr = fmt.Sprintf("repeat ×%d", m.state.RepeatCount)
+	default:
+		loopStr = "loop off"
+	}
+
+	title := fmt.Sprintf("Player | %s | %s | %s | %s", controls, speedStr, volumeStr, loopStr)
+	if m.autoplay {
+		title += " | autoplay"
+	}
+	if m.notice != nil {
+		title += fmt.Sprintf(" | Error: %v", m.notice)
+	}
+	return title
 }
 
 func (m PlayerModel) View() string {
@@ -137,8 +159,8 @@ func (m PlayerModel) View() string {
 			songInfo = songInfo[:m.width-5] + "..."
 		}
 
-		posStr := formatDuration(m.state.Position)
-		durStr := formatDuration(m.state.Duration)
+		posStr := format.Duration(m.state.Position)
+		durStr := format.Duration(m.state.Duration)
 
 		availableWidth := m.width - 2
 
@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"context"
+	"yogo/internal/domain"
+	"yogo/internal/ports"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type playlistTrackItem struct {
+	song  domain.Song
+	index int
+}
+
+func (i playlistTrackItem) FilterValue() string { return i.song.Title }
+func (i playlistTrackItem) ID() string          { return i.song.ID }
+func (i playlistTrackItem) ToSong() domain.Song { return i.song }
+
+type playlistDataSource struct {
+	youtubeService ports.YoutubeService
+	playlist       domain.Playlist
+}
+
+func (s playlistDataSource) Fetch(ctx context.Context, query string) tea.Msg {
+	if s.playlist.Songs != nil {
+		return ports.PlaylistLoadedMsg{Playlist: s.playlist}
+	}
+	playlist, err := s.youtubeService.GetPlaylist(s.playlist.ID)
+	if err != nil {
+		return ports.PlaylistErrorMsg{Err: err}
+	}
+	return ports.PlaylistLoadedMsg{Playlist: playlist}
+}
+
+func NewPlaylistModel(service ports.YoutubeService, playlist domain.Playlist, styles Styles, keys keyMap) listAndFilterModel {
+	return NewListAndFilterModel(
+		"playlist",
+		"Filter playlist...",
+		playlistDataSource{youtubeService: service, playlist: playlist},
+		styles,
+		keys,
+	)
+}
@@ -0,0 +1,36 @@
+package ui
+
+import (
+	"context"
+	"yogo/internal/domain"
+	"yogo/internal/ports"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type queueItem struct {
+	song  domain.Song
+	index int
+}
+
+func (i queueItem) FilterValue() string { return i.song.Title }
+func (i queueItem) ID() string          { return i.song.ID }
+func (i queueItem) ToSong() domain.Song { return i.song }
+
+type queueDataSource struct {
+	queueService ports.QueueService
+}
+
+func (s queueDataSource) Fetch(ctx context.Context, query string) tea.Msg {
+	return ports.QueueLoadedMsg{Songs: s.queueService.List()}
+}
+
+func NewQueueModel(service ports.QueueService, styles Styles, keys keyMap) listAndFilterModel {
+	return NewListAndFilterModel(
+		"queue",
+		"Filter queue...",
+		queueDataSource{queueService: service},
+		styles,
+		keys,
+	)
+}
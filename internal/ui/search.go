@@ -1,10 +1,14 @@
 package ui
 
 import (
+	"context"
+	"fmt"
 	"strings"
 	"yogo/internal/domain"
+	"yogo/internal/logger"
 	"yogo/internal/ports"
 
+	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -14,29 +18,164 @@ func (i searchItem) FilterValue() string { return i.song.Title }
 func (i searchItem) ID() string          { return i.song.ID }
 func (i searchItem) ToSong() domain.Song { return i.song }
 
+type playlistResultItem struct{ playlist domain.Playlist }
+
+func (i playlistResultItem) FilterValue() string { return i.playlist.Title }
+func (i playlistResultItem) ID() string          { return i.playlist.ID }
+func (i playlistResultItem) ToSong() domain.Song { return domain.Song{} }
+func (i playlistResultItem) Details() string {
+	kind := i.playlist.Kind
+	if kind == "" {
+		kind = "playlist"
+	}
+	parts := []string{kind}
+	if i.playlist.Count > 0 {
+		parts = append(parts, fmt.Sprintf("%d videos", i.playlist.Count))
+	}
+	if i.playlist.Author != "" {
+		parts = append(parts, i.playlist.Author)
+	}
+	return strings.Join(parts, " · ")
+}
+
+func searchItems(songs []domain.Song, playlists []domain.Playlist) []list.Item {
+	items := make([]list.Item, 0, len(playlists)+len(songs))
+	for _, playlist := range playlists {
+		items = append(items, playlistResultItem{playlist: playlist})
+	}
+	for _, song := range songs {
+		items = append(items, searchItem{song: song})
+	}
+	return items
+}
+
+const (
+	maxSuggestions   = 8
+	pastQueriesLimit = 100
+)
+
 type youtubeDataSource struct {
 	youtubeService ports.YoutubeService
+	storage        ports.StorageService
 	config         domain.Config
+	filter         string
+}
+
+func (s youtubeDataSource) Filter() string { return s.filter }
+
+func (s youtubeDataSource) NextFilter() listDataSource {
+	filtered, ok := s.youtubeService.(ports.FilteredSearchService)
+	if !ok {
+		return s
+	}
+	filters := filtered.SearchFilters()
+	if len(filters) == 0 {
+		return s
+	}
+	next := 0
+	for i, filter := range filters {
+		if filter == s.filter {
+			next = (i + 1) % len(filters)
+		}
+	}
+	s.filter = filters[next]
+	return s
+}
+
+func (s youtubeDataSource) searchPage(ctx context.Context, query, continuation string) (domain.SearchPage, error) {
+	if filtered, ok := s.youtubeService.(ports.FilteredSearchService); ok && s.filter != "" {
+		return filtered.SearchPageFiltered(ctx, query, s.filter, continuation)
+	}
+	return s.youtubeService.SearchPage(ctx, query, continuation)
 }
 
-func (s youtubeDataSource) Fetch(query string) tea.Msg {
-	songs, err := s.youtubeService.Search(query, s.config.SearchLimit)
+func (s youtubeDataSource) Fetch(ctx context.Context, query string) tea.Msg {
+	if query != "" && !strings.HasPrefix(query, "http") {
+		if err := s.storage.AddSearchQuery(query); err != nil {
+			logger.Log.Warn().Err(err).Msg("failed to save search query")
+		}
+	}
+
+	page, err := s.searchPage(ctx, query, "")
 	if err != nil {
-		return ports.SearchErrorMsg{Err: err}
+		return ports.SearchErrorMsg{Query: query, Err: err}
+	}
+
+	for len(page.Songs) < s.config.SearchLimit && page.Continuation != "" {
+		next, err := s.searchPage(ctx, query, page.Continuation)
+		if err != nil || len(next.Songs) == 0 {
+			break
+		}
+		page.Songs = append(page.Songs, next.Songs...)
+		page.Playlists = append(page.Playlists, next.Playlists...)
+		page.Continuation = next.Continuation
 	}
 
-	if len(songs) == 1 && strings.Contains(query, "http") {
-		return ports.PlaySongMsg{Song: songs[0]}
+	if strings.Contains(query, "http") {
+		if len(page.Songs) == 1 {
+			return ports.PlaySongMsg{Song: page.Songs[0]}
+		}
+		if len(page.Songs) == 0 && len(page.Playlists) == 1 {
+			return ports.OpenPlaylistMsg{Playlist: page.Playlists[0]}
+		}
 	}
 
-	return ports.SearchResultsMsg{Songs: songs}
+	return ports.SearchResultsMsg{Query: query, Songs: page.Songs, Playlists: page.Playlists, Continuation: page.Continuation}
 }
 
-func NewSearchModel(service ports.YoutubeService, cfg domain.Config, styles Styles) listAndFilterModel {
+func (s youtubeDataSource) FetchMore(ctx context.Context, token string) tea.Msg {
+	page, err := s.searchPage(ctx, "", token)
+	if err != nil {
+		return ports.SearchMoreErrorMsg{Token: token, Err: err}
+	}
+	return ports.SearchMoreResultsMsg{Token: token, Songs: page.Songs, Playlists: page.Playlists, Continuation: page.Continuation}
+}
+
+func (s youtubeDataSource) Suggest(query string) tea.Msg {
+	var suggestions []string
+	seen := make(map[string]bool)
+	add := func(suggestion string) {
+		normalized := strings.ToLower(suggestion)
+		if len(suggestions) < maxSuggestions && !seen[normalized] {
+			seen[normalized] = true
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+
+	prefix := strings.ToLower(query)
+	past, err := s.storage.GetSearchQueries(pastQueriesLimit)
+	if err != nil {
+		logger.Log.Warn().Err(err).Msg("failed to load past search queries")
+	}
+	for _, pastQuery := range past {
+		if strings.HasPrefix(strings.ToLower(pastQuery), prefix) && !strings.EqualFold(pastQuery, query) {
+			add(pastQuery)
+		}
+	}
+
+	remote, err := s.youtubeService.Suggest(query)
+	if err != nil {
+		logger.Log.Debug().Err(err).Str("query", query).Msg("failed to fetch search suggestions")
+	}
+	for _, suggestion := range remote {
+		if !strings.EqualFold(suggestion, query) {
+			add(suggestion)
+		}
+	}
+
+	return ports.SuggestionsMsg{Query: query, Suggestions: suggestions}
+}
+
+func NewSearchModel(service ports.YoutubeService, storage ports.StorageService, cfg domain.Config, styles Styles, keys keyMap) listAndFilterModel {
+	source := youtubeDataSource{youtubeService: service, storage: storage, config: cfg}
+	if _, ok := service.(ports.FilteredSearchService); ok {
+		source.filter = cfg.SearchFilter
+	}
 	return NewListAndFilterModel(
 		"search",
 		"Search for a song or paste a URL...",
-		youtubeDataSource{youtubeService: service, config: cfg},
+		source,
 		styles,
+		keys,
 	)
 }
@@ -1,12 +1,18 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
+	"time"
 	"yogo/internal/domain"
+	"yogo/internal/format"
+	"yogo/internal/logger"
 	"yogo/internal/ports"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
@@ -28,18 +34,48 @@ type listItem interface {
 }
 
 type listDataSource interface {
-	Fetch(query string) tea.Msg
+	Fetch(ctx context.Context, query string) tea.Msg
 }
 
+type pagedDataSource interface {
+	FetchMore(ctx context.Context, token string) tea.Msg
+}
+
+type suggestingDataSource interface {
+	Suggest(query string) tea.Msg
+}
+
+type filterableDataSource interface {
+	Filter() string
+	NextFilter() listDataSource
+}
+
+type detailedItem interface {
+	Details() string
+}
+
+type suggestTickMsg struct{ query string }
+
+const suggestDebounce = 250 * time.Millisecond
+
 type listAndFilterModel struct {
 	title             string
 	dataSource        listDataSource
 	styles            Styles
+	keys              keyMap
 	focus             componentFocus
 	textInput         textinput.Model
 	resultsList       list.Model
 	spinner           spinner.Model
 	isLoading         bool
+	isLoadingMore     bool
+	query             string
+	searchCtx         context.Context
+	cancel            context.CancelFunc
+	cancelMore        context.CancelFunc
+	continuation      string
+	suggestions       []string
+	suggestionIndex   int
 	err               error
 	fullList          []list.Item
 	markedForDeletion map[string]struct{}
@@ -76,21 +112,50 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, item list.Ite
 
 	lineBuilder.WriteString(listItem.FilterValue())
 	line := lineBuilder.String()
+	var details string
 
 	if m.Width() > 0 {
+		if detailed, ok := item.(detailedItem); ok {
+			details = detailed.Details()
+		} else {
+			details = songDetails(listItem.ToSong())
+		}
 		lineWidth := m.Width() - lipgloss.Width(itemStyle.Render(pointer))
+		if details != "" && lipgloss.Width(details)+10 < lineWidth {
+			lineWidth -= lipgloss.Width(details) + 2
+		} else {
+			details = ""
+		}
 		if len(line) > lineWidth {
 			line = line[:lineWidth-3] + "..."
 		}
+		if details != "" {
+			line += strings.Repeat(" ", lineWidth-lipgloss.Width(line)+2)
+		}
 	}
-	fmt.Fprint(w, itemStyle.Render(pointer+line))
+	fmt.Fprint(w, itemStyle.Render(pointer+line)+d.styles.ListDetails.Render(details))
 }
 
-func NewListAndFilterModel(title, placeholder string, source listDataSource, styles Styles) listAndFilterModel {
+func songDetails(song domain.Song) string {
+	var parts []string
+	if song.Duration > 0 {
+		parts = append(parts, format.Duration(float64(song.Duration)))
+	}
+	if artists := strings.Join(song.Artists, ", "); artists != "" {
+		parts = append(parts, artists)
+	}
+	if song.Album != "" {
+		parts = append(parts, song.Album)
+	}
+	return strings.Join(parts, " · ")
+}
+
+func NewListAndFilterModel(title, placeholder string, source listDataSource, styles Styles, keys keyMap) listAndFilterModel {
 	m := listAndFilterModel{
 		title:             title,
 		dataSource:        source,
 		styles:            styles,
+		keys:              keys,
 		focus:             inputFocus,
 		markedForDeletion: make(map[string]struct{}),
 	}
@@ -125,7 +190,7 @@ func (m *listAndFilterModel) Init() tea.Cmd {
 	m.isLoading = true
 	m.markedForDeletion = make(map[string]struct{})
 	fetchCmd := func() tea.Msg {
-		return m.dataSource.Fetch("")
+		return m.dataSource.Fetch(context.Background(), "")
 	}
 	return tea.Batch(m.spinner.Tick, fetchCmd)
 }
@@ -138,6 +203,7 @@ func (m *listAndFilterModel) Focus() tea.Cmd {
 func (m *listAndFilterModel) Blur() {
 	m.focus = inputFocus
 	m.textInput.Blur()
+	m.clearSuggestions()
 }
 
 func (m *listAndFilterModel) GetFocus() componentFocus { return m.focus }
@@ -161,14 +227,62 @@ func (m listAndFilterModel) Update(msg tea.Msg) (listAndFilterModel, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case ports.SearchResultsMsg:
+		if msg.Query != m.query {
+			return m, nil
+		}
 		m.isLoading = false
-		items := make([]list.Item, len(msg.Songs))
-		for i, song := range msg.Songs {
-			items[i] = searchItem{song: song}
+		m.resultsList.SetItems(searchItems(msg.Songs, msg.Playlists))
+		m.continuation = msg.Continuation
+		return m, nil
+	case ports.SearchMoreResultsMsg:
+		if msg.Token != m.continuation {
+			return m, nil
 		}
-		m.resultsList.SetItems(items)
+		m.stopLoadingMore()
+		m.continuation = msg.Continuation
+		items := append(m.resultsList.Items(), searchItems(msg.Songs, msg.Playlists)...)
+		return m, m.resultsList.SetItems(items)
+	case ports.SearchMoreErrorMsg:
+		if msg.Token != m.continuation || errors.Is(msg.Err, context.Canceled) {
+			return m, nil
+		}
+		m.stopLoadingMore()
+		logger.Log.Warn().Err(msg.Err).Msg("failed to load more search results")
 		return m, nil
 	case ports.SearchErrorMsg:
+		if msg.Query != m.query || errors.Is(msg.Err, context.Canceled) {
+			return m, nil
+		}
+		m.cancelSearch()
+		m.isLoading = false
+		m.err = msg.Err
+		return m, nil
+	case suggestTickMsg:
+		source, ok := m.dataSource.(suggestingDataSource)
+		if !ok || m.focus != inputFocus || msg.query != m.textInput.Value() {
+			return m, nil
+		}
+		return m, func() tea.Msg { return source.Suggest(msg.query) }
+	case ports.SuggestionsMsg:
+		if m.focus != inputFocus || msg.Query != m.textInput.Value() {
+			return m, nil
+		}
+		m.suggestions = msg.Suggestions
+		m.suggestionIndex = -1
+		return m, nil
+	case ports.OpenPlaylistMsg:
+		m.isLoading = false
+		return m, nil
+	case ports.PlaylistLoadedMsg:
+		m.isLoading = false
+		items := make([]list.Item, len(msg.Playlist.Songs))
+		for i, song := range msg.Playlist.Songs {
+			items[i] = playlistTrackItem{song: song, index: i}
+		}
+		m.fullList = items
+		m.resultsList.SetItems(items)
+		return m, nil
+	case ports.PlaylistErrorMsg:
 		m.isLoading = false
 		m.err = msg.Err
 		return m, nil
@@ -185,19 +299,48 @@ func (m listAndFilterModel) Update(msg tea.Msg) (listAndFilterModel, tea.Cmd) {
 		m.isLoading = false
 		m.err = msg.Err
 		return m, nil
+	case ports.QueueLoadedMsg:
+		m.isLoading = false
+		items := make([]list.Item, len(msg.Songs))
+		for i, song := range msg.Songs {
+			items[i] = queueItem{song: song, index: i}
+		}
+		m.fullList = items
+		m.resultsList.SetItems(items)
+		return m, nil
 	}
 
-	if m.isLoading {
+	if _, isKey := msg.(tea.KeyMsg); m.isLoading && !isKey {
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
 	}
 
+	if tick, ok := msg.(spinner.TickMsg); ok {
+		if !m.isLoadingMore {
+			return m, nil
+		}
+		m.spinner, cmd = m.spinner.Update(tick)
+		return m, cmd
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "esc":
+		switch {
+		case key.Matches(msg, m.keys.Back):
+			if m.isLoading && m.cancel != nil {
+				m.cancelSearch()
+				m.isLoading = false
+				m.query = ""
+				m.focus = inputFocus
+				return m, m.textInput.Focus()
+			}
+			if m.isLoadingMore {
+				m.stopLoadingMore()
+				return m, nil
+			}
 			return m, func() tea.Msg { return ports.ChangeFocusMsg{NewFocus: ports.GlobalFocus} }
-		case "tab":
+		case key.Matches(msg, m.keys.SwitchFocus):
+			m.clearSuggestions()
 			if m.focus == inputFocus {
 				m.focus = listFocus
 				m.textInput.Blur()
@@ -206,28 +349,52 @@ func (m listAndFilterModel) Update(msg tea.Msg) (listAndFilterModel, tea.Cmd) {
 				m.textInput.Focus()
 			}
 			return m, nil
+		case key.Matches(msg, m.keys.NextFilter):
+			source, ok := m.dataSource.(filterableDataSource)
+			if !ok || source.Filter() == "" {
+				break
+			}
+			m.dataSource = source.NextFilter()
+			if m.title == "search" && m.textInput.Value() != "" {
+				return m, m.startSearch()
+			}
+			return m, nil
 		}
 	}
 
 	switch m.focus {
 	case inputFocus:
+		if keyMsg, ok := msg.(tea.KeyMsg); ok && len(m.suggestions) > 0 {
+			switch keyMsg.Type {
+			case tea.KeyUp:
+				m.suggestionIndex = max(m.suggestionIndex-1, -1)
+				return m, nil
+			case tea.KeyDown:
+				m.suggestionIndex = min(m.suggestionIndex+1, len(m.suggestions)-1)
+				return m, nil
+			}
+			if key.Matches(keyMsg, m.keys.Select) && m.suggestionIndex >= 0 {
+				m.textInput.SetValue(m.suggestions[m.suggestionIndex])
+				m.textInput.CursorEnd()
+			}
+		}
+
+		previous := m.textInput.Value()
 		m.textInput, cmd = m.textInput.Update(msg)
 		cmds = append(cmds, cmd)
 
 		isSearch := m.title == "search"
 		if isSearch {
-			if key, ok := msg.(tea.KeyMsg); ok && key.String() == "enter" {
-				if m.isLoading || m.textInput.Value() == "" {
+			if keyMsg, ok := msg.(tea.KeyMsg); ok && key.Matches(keyMsg, m.keys.Select) {
+				if m.textInput.Value() == "" {
 					return m, nil
 				}
-				m.isLoading = true
-				m.err = nil
-				m.resultsList.SetItems([]list.Item{})
 				m.focus = listFocus
 				m.textInput.Blur()
-				cmds = append(cmds, func() tea.Msg {
-					return m.dataSource.Fetch(m.textInput.Value())
-				})
+				m.clearSuggestions()
+				cmds = append(cmds, m.startSearch())
+			} else if query := m.textInput.Value(); query != previous {
+				cmds = append(cmds, m.scheduleSuggestions(query))
 			}
 		} else {
 			filterTerm := m.textInput.Value()
@@ -245,13 +412,49 @@ func (m listAndFilterModel) Update(msg tea.Msg) (listAndFilterModel, tea.Cmd) {
 		}
 
 	case listFocus:
-		if key, ok := msg.(tea.KeyMsg); ok {
-			switch key.String() {
-			case "enter":
+		if keyMsg, ok := msg.(tea.KeyMsg); ok {
+			switch {
+			case key.Matches(keyMsg, m.keys.Select):
+				if selectedItem, ok := m.resultsList.SelectedItem().(playlistResultItem); ok {
+					return m, func() tea.Msg { return ports.OpenPlaylistMsg{Playlist: selectedItem.playlist} }
+				}
+				if selectedItem, ok := m.resultsList.SelectedItem().(playlistTrackItem); ok {
+					songs := make([]domain.Song, 0, len(m.fullList)-selectedItem.index)
+					for _, item := range m.fullList[selectedItem.index:] {
+						songs = append(songs, item.(playlistTrackItem).song)
+					}
+					return m, func() tea.Msg { return ports.PlayPlaylistMsg{Songs: songs} }
+				}
+				if selectedItem, ok := m.resultsList.SelectedItem().(queueItem); ok {
+					return m, func() tea.Msg { return ports.PlayQueueItemMsg{Index: selectedItem.index} }
+				}
 				if selectedItem, ok := m.resultsList.SelectedItem().(listItem); ok {
 					return m, func() tea.Msg { return ports.PlaySongMsg{Song: selectedItem.ToSong()} }
 				}
-			case "x":
+			case key.Matches(keyMsg, m.keys.AddToQueue, m.keys.PlayNext):
+				if m.title != "queue" {
+					if selectedItem, ok := m.resultsList.SelectedItem().(listItem); ok && selectedItem.ToSong().ID != "" {
+						playNext := key.Matches(keyMsg, m.keys.PlayNext)
+						return m, func() tea.Msg { return ports.QueueSongMsg{Song: selectedItem.ToSong(), PlayNext: playNext} }
+					}
+				}
+			case key.Matches(keyMsg, m.keys.MoveUp, m.keys.MoveDown):
+				if selectedItem, ok := m.resultsList.SelectedItem().(queueItem); ok {
+					to := selectedItem.index + 1
+					if key.Matches(keyMsg, m.keys.MoveUp) {
+						to = selectedItem.index - 1
+					}
+					if to < 0 || to >= len(m.fullList) || len(m.resultsList.Items()) != len(m.fullList) {
+						return m, nil
+					}
+					m.resultsList.Select(to)
+					return m, func() tea.Msg { return ports.MoveInQueueMsg{From: selectedItem.index, To: to} }
+				}
+			case key.Matches(keyMsg, m.keys.ClearQueue):
+				if m.title == "queue" && len(m.fullList) > 0 {
+					return m, func() tea.Msg { return ports.ClearQueueMsg{} }
+				}
+			case key.Matches(keyMsg, m.keys.Mark):
 				if m.title == "history" {
 					if selectedItem, ok := m.resultsList.SelectedItem().(listItem); ok {
 						songID := selectedItem.ID()
@@ -264,7 +467,10 @@ func (m listAndFilterModel) Update(msg tea.Msg) (listAndFilterModel, tea.Cmd) {
 						return m, cmd
 					}
 				}
-			case "d":
+			case key.Matches(keyMsg, m.keys.Delete):
+				if selectedItem, ok := m.resultsList.SelectedItem().(queueItem); ok {
+					return m, func() tea.Msg { return ports.RemoveFromQueueMsg{Index: selectedItem.index} }
+				}
 				if m.title == "history" && len(m.markedForDeletion) > 0 {
 					ids := make([]string, 0, len(m.markedForDeletion))
 					for id := range m.markedForDeletion {
@@ -275,12 +481,82 @@ func (m listAndFilterModel) Update(msg tea.Msg) (listAndFilterModel, tea.Cmd) {
 			}
 		}
 		m.resultsList, cmd = m.resultsList.Update(msg)
-		cmds = append(cmds, cmd)
+		cmds = append(cmds, cmd, m.loadMore())
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
+func (m *listAndFilterModel) startSearch() tea.Cmd {
+	m.cancelSearch()
+	ctx, cancel := context.WithCancel(context.Background())
+	m.searchCtx, m.cancel = ctx, cancel
+	m.query = m.textInput.Value()
+	m.isLoading = true
+	m.continuation = ""
+	m.err = nil
+	m.resultsList.SetItems([]list.Item{})
+
+	source, query := m.dataSource, m.query
+	return tea.Batch(m.spinner.Tick, func() tea.Msg {
+		return source.Fetch(ctx, query)
+	})
+}
+
+func (m *listAndFilterModel) cancelSearch() {
+	m.stopLoadingMore()
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+	m.searchCtx = nil
+}
+
+func (m *listAndFilterModel) stopLoadingMore() {
+	if m.cancelMore != nil {
+		m.cancelMore()
+		m.cancelMore = nil
+	}
+	m.isLoadingMore = false
+}
+
+func (m *listAndFilterModel) scheduleSuggestions(query string) tea.Cmd {
+	m.clearSuggestions()
+	if _, ok := m.dataSource.(suggestingDataSource); !ok || strings.TrimSpace(query) == "" || strings.HasPrefix(query, "http") {
+		return nil
+	}
+	return tea.Tick(suggestDebounce, func(time.Time) tea.Msg {
+		return suggestTickMsg{query: query}
+	})
+}
+
+func (m *listAndFilterModel) clearSuggestions() {
+	m.suggestions = nil
+	m.suggestionIndex = -1
+}
+
+func (m *listAndFilterModel) loadMore() tea.Cmd {
+	source, ok := m.dataSource.(pagedDataSource)
+	if !ok || m.continuation == "" || m.isLoadingMore {
+		return nil
+	}
+	if m.resultsList.Index() < len(m.resultsList.Items())-1 {
+		return nil
+	}
+
+	parent := m.searchCtx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	m.cancelMore = cancel
+	m.isLoadingMore = true
+	token := m.continuation
+	return tea.Batch(m.spinner.Tick, func() tea.Msg {
+		return source.FetchMore(ctx, token)
+	})
+}
+
 func (m listAndFilterModel) View() (string, string) {
 	var mainView string
 	if m.isLoading {
@@ -291,9 +567,21 @@ func (m listAndFilterModel) View() (string, string) {
 		mainView = m.resultsList.View()
 	}
 
-	footerView := lipgloss.JoinVertical(lipgloss.Left,
-		m.textInput.View(),
-		"",
-	)
+	status := ""
+	if m.isLoadingMore {
+		status = m.spinner.View() + " Loading more..."
+	} else if source, ok := m.dataSource.(filterableDataSource); ok && source.Filter() != "" {
+		status = m.styles.ListDetails.Render("filter: " + source.Filter())
+	}
+	lines := []string{m.textInput.View()}
+	for i, suggestion := range m.suggestions {
+		if i == m.suggestionIndex {
+			lines = append(lines, m.styles.ListSelected.Render(m.styles.ListPointer.String()+suggestion))
+		} else {
+			lines = append(lines, m.styles.ListDetails.Render("  "+suggestion))
+		}
+	}
+	lines = append(lines, status)
+	footerView := lipgloss.JoinVertical(lipgloss.Left, lines...)
 	return mainView, footerView
 }
@@ -22,6 +22,7 @@ type Styles struct {
 	ListPointer  lipgloss.Style
 	ListSelected lipgloss.Style
 	ListNormal   lipgloss.Style
+	ListDetails  lipgloss.Style
 	ErrorText    lipgloss.Style
 }
 
@@ -37,6 +38,7 @@ func DefaultStyles() Styles {
 	s.ListPointer = lipgloss.NewStyle().SetString("> ")
 	s.ListSelected = lipgloss.NewStyle().Bold(true).Foreground(colorMagenta)
 	s.ListNormal = lipgloss.NewStyle()
+	s.ListDetails = lipgloss.NewStyle().Faint(true)
 	s.ErrorText = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
 
 	return s